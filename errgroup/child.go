@@ -0,0 +1,112 @@
+package errgroup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupError wraps an error which propagated into a group from one of its descendants
+// (see [ContextGroup.Child]). It is the cause of the ancestor groups' contexts, and the
+// error returned from their Wait.
+type GroupError struct {
+	// Path names the groups from the outermost, to the one whose task failed.
+	Path []string
+	// Err is the error recorded by the group whose task failed.
+	Err error
+}
+
+var _ error = (*GroupError)(nil)
+
+func (e *GroupError) Error() string {
+	return fmt.Sprintf("errgroup: group %s: %v", strings.Join(e.Path, "/"), e.Err)
+}
+
+// Unwrap returns the error recorded by the group whose task failed.
+func (e *GroupError) Unwrap() error {
+	return e.Err
+}
+
+// Child creates a sub-group whose context derives from the group context.
+func (g *ctxGroup) Child(name string) ContextGroup {
+	c := newBuilder().build(g.ctx)
+	c.parent = g
+	c.path = append(g.path[:len(g.path):len(g.path)], name)
+
+	g.mu.Lock()
+	g.children[c] = struct{}{}
+	draining := g.draining
+	g.mu.Unlock()
+	if draining {
+		c.beginDrain()
+	}
+	return c
+}
+
+// trackLocked adds a task to the outstanding tasks of the group and all of its ancestors, unless
+// any of them is shutting down. The caller must hold mu.
+func (g *ctxGroup) trackLocked() bool {
+	if g.draining {
+		return false
+	}
+	if p := g.parent; p != nil {
+		p.mu.Lock()
+		ok := p.trackLocked()
+		p.mu.Unlock()
+		if !ok {
+			return false
+		}
+	}
+	g.wg.Add(1)
+	return true
+}
+
+// untrack removes a task added by trackLocked.
+func (g *ctxGroup) untrack() {
+	g.wg.Done()
+	if g.parent != nil {
+		g.parent.untrack()
+	}
+}
+
+// beginDrain stops the group and all of its descendants from accepting new tasks, and signals
+// running tasks via Draining.
+func (g *ctxGroup) beginDrain() {
+	g.mu.Lock()
+	if g.draining {
+		g.mu.Unlock()
+		return
+	}
+	g.draining = true
+	close(g.drain)
+	children := make([]*ctxGroup, 0, len(g.children))
+	for c := range g.children {
+		children = append(children, c)
+	}
+	g.mu.Unlock()
+
+	for _, c := range children {
+		c.beginDrain()
+	}
+}
+
+// propagate records the first error of a child group in its parent, which cancels the parent and
+// therefore all of the child's siblings.
+func (g *ctxGroup) propagate(err error) {
+	p := g.parent
+	if p == nil || p.ctx.Err() != nil {
+		return // nothing to do, or the parent has already failed or finished
+	}
+	if _, ok := err.(*GroupError); !ok {
+		err = &GroupError{Path: g.path, Err: err}
+	}
+	p.error(err)
+}
+
+// detach forgets a finished child group.
+func (g *ctxGroup) detach() {
+	if p := g.parent; p != nil {
+		p.mu.Lock()
+		delete(p.children, g)
+		p.mu.Unlock()
+	}
+}
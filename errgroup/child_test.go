@@ -0,0 +1,174 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestChildWait(t *testing.T) {
+	g := errgroup.New(context.Background())
+	child := g.Child("child")
+
+	var finished atomic.Bool
+	release := make(chan struct{})
+	child.Go(func(ctx context.Context) error {
+		<-release
+		finished.Store(true)
+		return nil
+	})
+
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Load() {
+		t.Fatal("parent Wait returned before the child's task")
+	}
+	if err := child.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChildSuccess(t *testing.T) {
+	g := errgroup.New(context.Background())
+	var parentCtx context.Context
+	g.Go(func(ctx context.Context) error {
+		parentCtx = ctx
+		child := g.Child("child")
+		child.Go(func(ctx context.Context) error { return nil })
+		return child.Wait()
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if cause := context.Cause(parentCtx); cause != context.Canceled {
+		t.Errorf("got cause: %v, want: %v", cause, context.Canceled)
+	}
+}
+
+func TestChildError(t *testing.T) {
+	errDoom := errors.New("child_test: doomed")
+	g := errgroup.WithName("root").New(context.Background())
+	child := g.Child("fetch")
+
+	var siblingCause error
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		siblingCause = context.Cause(ctx)
+		return nil
+	})
+	child.Go(func(ctx context.Context) error {
+		return errDoom
+	})
+
+	if err := child.Wait(); err != errDoom {
+		t.Errorf("got child error: %v, want: %v", err, errDoom)
+	}
+	err := g.Wait()
+	var ge *errgroup.GroupError
+	if !errors.As(err, &ge) {
+		t.Fatalf("expected group error, got: %v", err)
+	}
+	if want := []string{"root", "fetch"}; !reflect.DeepEqual(ge.Path, want) {
+		t.Errorf("got path: %q, want: %q", ge.Path, want)
+	}
+	if !errors.Is(err, errDoom) {
+		t.Errorf("expected group error to wrap %v", errDoom)
+	}
+	if want := "errgroup: group root/fetch: child_test: doomed"; err.Error() != want {
+		t.Errorf("got: %q, want: %q", err.Error(), want)
+	}
+	if siblingCause != err {
+		t.Errorf("got sibling cause: %v, want: %v", siblingCause, err)
+	}
+}
+
+func TestGrandchildError(t *testing.T) {
+	errDoom := errors.New("child_test: doomed")
+	g := errgroup.New(context.Background())
+	child := g.Child("a")
+	grandchild := child.Child("b")
+	grandchild.Go(func(ctx context.Context) error {
+		panic(errDoom)
+	})
+
+	err := g.Wait()
+	var ge *errgroup.GroupError
+	if !errors.As(err, &ge) {
+		t.Fatalf("expected group error, got: %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(ge.Path, want) {
+		t.Errorf("got path: %q, want: %q", ge.Path, want)
+	}
+	var pe *errgroup.PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("expected group error to wrap a panic error, got: %v", ge.Err)
+	}
+	// The intermediate group reports the same error, since it also propagated through it.
+	if cerr := child.Wait(); cerr != err {
+		t.Errorf("got child error: %v, want: %v", cerr, err)
+	}
+	if gerr := grandchild.Wait(); gerr != ge.Err {
+		t.Errorf("got grandchild error: %v, want: %v", gerr, ge.Err)
+	}
+}
+
+func TestChildCancelledByParent(t *testing.T) {
+	errDoom := errors.New("child_test: doomed")
+	g := errgroup.New(context.Background())
+	child := g.Child("child")
+
+	var childCause error
+	child.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		childCause = context.Cause(ctx)
+		return ctx.Err()
+	})
+	g.Go(func(ctx context.Context) error {
+		return errDoom
+	})
+
+	if err := g.Wait(); err != errDoom {
+		t.Fatalf("got: %v, want: %v", err, errDoom)
+	}
+	if childCause != errDoom {
+		t.Errorf("got child cause: %v, want: %v", childCause, errDoom)
+	}
+	if err := child.Wait(); err != context.Canceled {
+		t.Errorf("got child error: %v, want: %v", err, context.Canceled)
+	}
+}
+
+func TestChildShutdown(t *testing.T) {
+	g := errgroup.New(context.Background())
+	child := g.Child("child")
+	child.Go(drainingTask)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither the child, nor a new child, accepts work once the parent is shutting down.
+	var called atomic.Bool
+	child.Go(func(ctx context.Context) error {
+		called.Store(true)
+		return nil
+	})
+	if err := g.Child("late").Submit(0, drainingTask); err != errgroup.ErrShutdown {
+		t.Errorf("got: %v, want: %v", err, errgroup.ErrShutdown)
+	}
+	if err := child.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if called.Load() {
+		t.Error("child task started after shutdown")
+	}
+}
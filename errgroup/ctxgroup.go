@@ -3,6 +3,7 @@ package errgroup
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 type ctxGroup struct {
@@ -11,19 +12,57 @@ type ctxGroup struct {
 
 	wg sync.WaitGroup
 
-	sem chan token
+	sem     chan token
+	weights *weighted
+	rate    *tokenBucket
+
+	taskTimeout time.Duration
+	observe     func(latency time.Duration, err error)
+	nonFatal    func(error) bool
+
+	// drain is closed when Shutdown begins; guarded by mu, along with the fields below.
+	drain      chan struct{}
+	mu         sync.Mutex
+	draining   bool
+	lastTaskID int64
+	active     map[int64]func(context.Context) error
+	queue      taskQueue
+	maxQueue   int
+	queueSeq   int64
+	watching   bool // whether a goroutine is watching ctx and the weighted limit to dispatch the queue
+	retrying   bool // whether a dispatch is scheduled to retry a rate limited queue
+	completed  int
+	failed     int
+	errs       []*TaskError // non-fatal errors
+
+	// parent is the group which created this group via Child, if any. Tasks of this group are
+	// also outstanding tasks of its ancestors, and its first error propagates to them.
+	parent   *ctxGroup
+	path     []string               // names of the group and its ancestors, outermost first
+	children map[*ctxGroup]struct{} // guarded by mu
 
 	errOnce sync.Once
-	err     error
+	errDone chan struct{} // closed once err is set
+	err     error         // guarded by mu, once set
 }
 
 var _ ContextGroup = (*ctxGroup)(nil)
 
-func (g *ctxGroup) done() {
-	if g.sem != nil {
-		<-g.sem
+func (g *ctxGroup) done(id int64, cfg taskConfig, failed bool) {
+	g.mu.Lock()
+	delete(g.active, id)
+	if failed {
+		g.failed++
+	} else {
+		g.completed++
 	}
-	g.wg.Done()
+	// Release while holding mu, so that Status never sees the next task start before this one is counted.
+	g.release(cfg)
+	if len(g.queue) > 0 {
+		g.dispatchLocked()
+	}
+	g.mu.Unlock()
+	g.untrack()
 }
 
 // New returns a new ContextGroup derived from ctx.
@@ -31,108 +70,429 @@ func (g *ctxGroup) done() {
 // All funcs passed into [ContextGroup.Go] are wrapped with panic handlers and receive the
 // group context automatically.
 func New(ctx context.Context) ContextGroup {
-	ctx, cancel := context.WithCancelCause(ctx)
-	return &ctxGroup{ctx: ctx, cancel: cancel}
+	return newBuilder().New(ctx)
 }
 
 // Wait blocks until all function calls from the Go method have returned, then returns the first non-nil error (if any) from them.
 func (g *ctxGroup) Wait() error {
 	g.wg.Wait()
 	g.cancel(g.err)
-	return g.err
+	g.detach()
+	return g.result()
 }
 
 // Go calls the given function in a new goroutine.
 func (g *ctxGroup) Go(f func(context.Context) error) {
+	g.submit(g.newTaskConfig(), f)
+}
+
+// GoWithTimeout calls the given function in a new goroutine, with a context that expires after timeout.
+func (g *ctxGroup) GoWithTimeout(timeout time.Duration, f func(context.Context) error) {
+	cfg := g.newTaskConfig()
+	cfg.timeout = timeout
+	g.submit(cfg, f)
+}
+
+// GoWeighted calls the given function in a new goroutine, once weight is available under the weighted limit.
+func (g *ctxGroup) GoWeighted(weight int64, f func(context.Context) error) {
+	cfg := g.newTaskConfig()
+	cfg.weight = weight
+	g.submit(cfg, f)
+}
+
+func (g *ctxGroup) TryGo(f func(context.Context) error) bool {
+	cfg := g.newTaskConfig()
+	if g.sem != nil {
+		select {
+		case g.sem <- token{}:
+			// Note: this allows barging iff channels in general allow barging.
+		case <-g.ctx.Done():
+			g.abandon()
+			return true
+		case <-g.drain:
+			return true
+		default:
+			return false
+		}
+	}
+	if g.weights != nil && !g.weights.tryAcquire(cfg.weight) {
+		if g.sem != nil {
+			<-g.sem
+		}
+		return false
+	}
+	if g.rate != nil && !g.rate.tryTake() {
+		g.release(cfg)
+		return false
+	}
+
+	if g.ctx.Err() != nil {
+		g.release(cfg)
+		g.abandon()
+		return true
+	}
+
+	g.start(cfg, f)
+	return true
+}
+
+// GoRetry calls the given function in a new goroutine via Go, retrying it according to the given policy.
+func (g *ctxGroup) GoRetry(p Policy, f func(context.Context) error) {
+	g.Go(func(ctx context.Context) error {
+		return Retry(ctx, p, f)
+	})
+}
+
+// taskConfig holds the settings for a single task.
+type taskConfig struct {
+	timeout  time.Duration
+	weight   int64
+	nonFatal func(error) bool // reports whether an error should not cancel the group
+}
+
+func (g *ctxGroup) newTaskConfig() taskConfig {
+	return taskConfig{timeout: g.taskTimeout, weight: 1, nonFatal: g.nonFatal}
+}
+
+// submit blocks until the task described by cfg may start, then starts it.
+func (g *ctxGroup) submit(cfg taskConfig, f func(context.Context) error) {
 	if g.sem != nil {
 		select {
 		case <-g.ctx.Done():
-			g.error(g.ctx.Err())
+			g.abandon()
+			return
+		case <-g.drain:
 			return
 		case g.sem <- token{}:
 		}
 	}
+	if g.weights != nil && !g.weights.acquire(g.ctx, g.drain, cfg.weight) {
+		if g.sem != nil {
+			<-g.sem
+		}
+		g.abandon()
+		return
+	}
+	// Take a rate token last, so that the task starts as soon as it is granted.
+	if g.rate != nil && !g.rate.wait(g.ctx, g.drain) {
+		g.release(cfg)
+		g.abandon()
+		return
+	}
 
-	if err := g.ctx.Err(); err != nil {
-		g.error(err)
+	if g.ctx.Err() != nil {
+		g.release(cfg)
+		g.abandon()
 		return
 	}
 
-	g.wg.Add(1)
-	go func() {
-		defer g.done()
-		panicked := true
-		defer func() {
-			if panicked {
-				g.error(NewPanicErrorCallers(recover(), 2))
-			}
-		}()
-		err := f(g.ctx)
-		panicked = false
-		if err != nil {
-			g.error(err)
-		}
-	}()
+	g.start(cfg, f)
 }
 
-func (g *ctxGroup) TryGo(f func(context.Context) error) bool {
+// tryAcquire acquires the limits for a task without blocking. If it fails because of the rate limit,
+// rateLimited is true.
+func (g *ctxGroup) tryAcquire(cfg taskConfig) (ok bool, rateLimited bool) {
 	if g.sem != nil {
 		select {
 		case g.sem <- token{}:
-			// Note: this allows barging iff channels in general allow barging.
-		case <-g.ctx.Done():
-			g.error(g.ctx.Err())
-			return true
 		default:
-			return false
+			return false, false
+		}
+	}
+	if g.weights != nil && !g.weights.tryAcquire(cfg.weight) {
+		if g.sem != nil {
+			<-g.sem
 		}
+		return false, false
 	}
+	if g.rate != nil && !g.rate.tryTake() {
+		g.release(cfg)
+		return false, true
+	}
+	return true, false
+}
 
-	if err := g.ctx.Err(); err != nil {
-		g.error(err)
-		return true
+// release returns the limits acquired on behalf of a task.
+func (g *ctxGroup) release(cfg taskConfig) {
+	if g.weights != nil {
+		g.weights.release(cfg.weight)
 	}
+	if g.sem != nil {
+		<-g.sem
+	}
+}
 
-	g.wg.Add(1)
-	go func() {
-		defer g.done()
-		panicked := true
-		defer func() {
-			if panicked {
-				g.error(NewPanicErrorCallers(recover(), 2))
-			}
-		}()
-		err := f(g.ctx)
-		panicked = false
-		if err != nil {
+// start calls f in a new goroutine, unless the group is shutting down.
+// The caller must hold any limits for the task; they are released if f is not started.
+func (g *ctxGroup) start(cfg taskConfig, f func(context.Context) error) {
+	g.mu.Lock()
+	if !g.trackLocked() {
+		g.mu.Unlock()
+		g.release(cfg)
+		return
+	}
+	g.launchLocked(cfg, f)
+	g.mu.Unlock()
+}
+
+// launchLocked calls f in a new goroutine. The caller must hold mu, any limits for the task,
+// and have already tracked the task.
+func (g *ctxGroup) launchLocked(cfg taskConfig, f func(context.Context) error) {
+	g.lastTaskID++
+	id := g.lastTaskID
+	g.active[id] = f
+	go g.run(id, cfg, f)
+}
+
+// run executes a single task in the current goroutine, catching panics and recording errors.
+func (g *ctxGroup) run(id int64, cfg taskConfig, f func(context.Context) error) {
+	failed := true // unless f returns nil
+	defer func() {
+		g.done(id, cfg, failed)
+	}()
+
+	ctx := g.ctx
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	panicked := true
+	defer func() {
+		if panicked {
+			err := NewPanicErrorCallers(recover(), 2)
+			g.observeTask(started, err)
 			g.error(err)
 		}
 	}()
-	return true
+	err := f(ctx)
+	panicked = false
+	failed = err != nil
+	g.observeTask(started, err)
+	if err != nil {
+		// Only blame the task's own timeout if the group itself is still alive.
+		if cfg.timeout > 0 && ctx.Err() == context.DeadlineExceeded && g.ctx.Err() == nil {
+			err = &TaskTimeoutError{Task: newTask(id, f), Timeout: cfg.timeout, Err: err}
+		}
+		if cfg.nonFatal != nil && cfg.nonFatal(err) {
+			g.nonFatalError(newTask(id, f), err)
+			return
+		}
+		g.error(err)
+	}
+}
+
+// observeTask reports the outcome of a task to the group's observer, if any.
+func (g *ctxGroup) observeTask(started time.Time, err error) {
+	if g.observe != nil {
+		g.observe(time.Since(started), err)
+	}
+}
+
+// abandon records the group context's error when a task is not started, unless the group is shutting down.
+func (g *ctxGroup) abandon() {
+	select {
+	case <-g.drain:
+	default:
+		g.error(g.ctx.Err())
+	}
 }
 
 func (g *ctxGroup) error(err error) {
 	g.errOnce.Do(func() {
+		g.mu.Lock()
 		g.err = err
+		g.mu.Unlock()
+		close(g.errDone)
 		g.cancel(err)
+		g.propagate(err)
 	})
 }
 
 type ctxGroupBuilder struct {
-	limit int
+	limit         int
+	weightedLimit int64
+	limiter       *Limiter
+	maxQueue      int
+	rate          float64
+	burst         int
+	observe       func(latency time.Duration, err error)
+	taskTimeout   time.Duration
+	nonFatal      func(error) bool
+	name          string
+}
+
+func newBuilder() ctxGroupBuilder {
+	return ctxGroupBuilder{limit: -1, weightedLimit: -1}
 }
 
 func (b ctxGroupBuilder) New(ctx context.Context) ContextGroup {
-	ctx, cancel := context.WithCancelCause(ctx)
+	return b.build(ctx)
+}
+
+func (b ctxGroupBuilder) build(ctx context.Context) *ctxGroup {
+	drain := make(chan struct{})
+	ctx, cancel := context.WithCancelCause(context.WithValue(ctx, drainKey{}, drain))
 	var sem chan token
 	if b.limit >= 0 {
 		sem = make(chan token, b.limit)
 	}
-	return &ctxGroup{ctx: ctx, cancel: cancel, sem: sem}
+	var weights *weighted
+	if b.limiter != nil {
+		weights = b.limiter.w
+	} else if b.weightedLimit >= 0 {
+		weights = newWeighted(b.weightedLimit)
+	}
+	var rate *tokenBucket
+	if b.rate > 0 {
+		rate = newTokenBucket(b.rate, b.burst)
+	}
+	var path []string
+	if b.name != "" {
+		path = []string{b.name}
+	}
+	return &ctxGroup{
+		ctx:         ctx,
+		cancel:      cancel,
+		sem:         sem,
+		weights:     weights,
+		rate:        rate,
+		taskTimeout: b.taskTimeout,
+		observe:     b.observe,
+		nonFatal:    b.nonFatal,
+		drain:       drain,
+		maxQueue:    b.maxQueue,
+		active:      map[int64]func(context.Context) error{},
+		path:        path,
+		children:    map[*ctxGroup]struct{}{},
+		errDone:     make(chan struct{}),
+	}
+}
+
+// WithLimit limits the number of active goroutines in the new group to at most n.
+// A negative value indicates no limit.
+func (b ctxGroupBuilder) WithLimit(limit int) ctxGroupBuilder {
+	b.limit = limit
+	return b
+}
+
+// WithWeightedLimit limits the total weight of active goroutines in the new group to at most capacity.
+// Tasks started via [ContextGroup.GoWeighted] consume their given weight; all other tasks have weight 1.
+// A negative value indicates no limit.
+func (b ctxGroupBuilder) WithWeightedLimit(capacity int64) ctxGroupBuilder {
+	b.weightedLimit = capacity
+	b.limiter = nil
+	return b
+}
+
+// WithLimiter limits the total weight of active goroutines in the new group using the given Limiter,
+// which may be resized at any time and shared between groups. Tasks started via [ContextGroup.GoWeighted]
+// consume their given weight; all other tasks have weight 1. Replaces any WithWeightedLimit.
+func (b ctxGroupBuilder) WithLimiter(l *Limiter) ctxGroupBuilder {
+	b.limiter = l
+	b.weightedLimit = -1
+	return b
+}
+
+// WithAIMD limits the new group using the controller's Limiter, and reports the latency and error
+// of each completed task to the controller, which adapts the limit.
+func (b ctxGroupBuilder) WithAIMD(a *AIMD) ctxGroupBuilder {
+	b = b.WithLimiter(a.Limiter)
+	b.observe = a.Observe
+	return b
+}
+
+// WithRateLimit limits the rate at which tasks start in the new group to at most rate per second,
+// with bursts of up to burst tasks. [ContextGroup.Go] blocks until the task may start, while
+// [ContextGroup.TryGo] returns false. A non-positive rate indicates no limit.
+//
+// The rate limit composes with any concurrency limit: a task must satisfy both before it starts.
+func (b ctxGroupBuilder) WithRateLimit(rate float64, burst int) ctxGroupBuilder {
+	b.rate = rate
+	b.burst = burst
+	return b
+}
+
+// WithQueue allows up to maxLen tasks submitted via [ContextGroup.Submit] to wait in the new group's
+// queue while the group is at its limit.
+func (b ctxGroupBuilder) WithQueue(maxLen int) ctxGroupBuilder {
+	b.maxQueue = maxLen
+	return b
+}
+
+// WithTaskTimeout sets a default timeout for each task started via [ContextGroup.Go]
+// or [ContextGroup.TryGo] in the new group. A non-positive value indicates no timeout.
+func (b ctxGroupBuilder) WithTaskTimeout(timeout time.Duration) ctxGroupBuilder {
+	b.taskTimeout = timeout
+	return b
+}
+
+// WithNonFatal sets a classifier for task errors in the new group: errors for which nonFatal returns
+// true are recorded and reported by Wait, but do not cancel the group. Panics are always fatal.
+// Per-task [NonFatal] and [Fatal] options override the classifier.
+func (b ctxGroupBuilder) WithNonFatal(nonFatal func(error) bool) ctxGroupBuilder {
+	b.nonFatal = nonFatal
+	return b
+}
+
+// WithName names the new group, as the outermost element of the Path of any [GroupError]
+// propagated into it from a child group.
+func (b ctxGroupBuilder) WithName(name string) ctxGroupBuilder {
+	b.name = name
+	return b
 }
 
 // WithLimit begins creating a New ContextGroup which limits the number of
 // active goroutines in this group to at most n. A negative value indicates no limit.
 func WithLimit(limit int) ctxGroupBuilder {
-	return ctxGroupBuilder{limit: limit}
+	return newBuilder().WithLimit(limit)
+}
+
+// WithTaskTimeout begins creating a New ContextGroup in which each task receives a context
+// that expires after the given timeout. A non-positive value indicates no timeout.
+func WithTaskTimeout(timeout time.Duration) ctxGroupBuilder {
+	return newBuilder().WithTaskTimeout(timeout)
+}
+
+// WithWeightedLimit begins creating a New ContextGroup which limits the total weight of
+// active goroutines in this group to at most capacity. A negative value indicates no limit.
+func WithWeightedLimit(capacity int64) ctxGroupBuilder {
+	return newBuilder().WithWeightedLimit(capacity)
+}
+
+// WithLimiter begins creating a New ContextGroup which limits the total weight of active goroutines
+// using the given Limiter, which may be resized at any time.
+func WithLimiter(l *Limiter) ctxGroupBuilder {
+	return newBuilder().WithLimiter(l)
+}
+
+// WithAIMD begins creating a New ContextGroup whose limit is adapted by the given AIMD controller.
+func WithAIMD(a *AIMD) ctxGroupBuilder {
+	return newBuilder().WithAIMD(a)
+}
+
+// WithRateLimit begins creating a New ContextGroup which limits the rate at which tasks start
+// to at most rate per second, with bursts of up to burst tasks. A non-positive rate indicates no limit.
+func WithRateLimit(rate float64, burst int) ctxGroupBuilder {
+	return newBuilder().WithRateLimit(rate, burst)
+}
+
+// WithQueue begins creating a New ContextGroup which allows up to maxLen tasks submitted via
+// [ContextGroup.Submit] to wait in a priority queue while the group is at its limit.
+func WithQueue(maxLen int) ctxGroupBuilder {
+	return newBuilder().WithQueue(maxLen)
+}
+
+// WithName begins creating a New ContextGroup with the given name, which identifies it
+// in the Path of any [GroupError].
+func WithName(name string) ctxGroupBuilder {
+	return newBuilder().WithName(name)
+}
+
+// WithNonFatal begins creating a New ContextGroup in which task errors for which nonFatal returns
+// true do not cancel the group.
+func WithNonFatal(nonFatal func(error) bool) ctxGroupBuilder {
+	return newBuilder().WithNonFatal(nonFatal)
 }
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/fullstorydev/go/errgroup"
+	"github.com/fullstorydev/go/errgroup/errgrouptest"
 )
 
 // JustErrors illustrates the use of a Group in place of a sync.WaitGroup to
@@ -259,6 +260,7 @@ func TestNewPanic(t *testing.T) {
 }
 
 func TestNewGoExitsEarly(t *testing.T) {
+	leaks := errgrouptest.VerifyNoLeaks(t)
 	var counter atomic.Uint32
 
 	fn := func(ctx context.Context) error {
@@ -274,7 +276,7 @@ func TestNewGoExitsEarly(t *testing.T) {
 	g.Go(fn) // this should succeed
 	g.Go(fn) // this should get stuck, then cancelled
 
-	_ = g.Wait()
+	_ = leaks.Wait(g)
 
 	if count := counter.Load(); count != 1 {
 		t.Fatalf("Counter should be 1, got %d", count)
@@ -2,7 +2,10 @@
 // but also introducing new, safer APIs.
 package errgroup
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ErrGroup defines a compatibility interface between [Group] and [golang.org/x/sync/errgroup.Group].
 type ErrGroup interface {
@@ -46,7 +49,11 @@ type ContextGroup interface {
 	// Go returns immediately if the group context is already cancelled.
 	Go(func(context.Context) error)
 	// Wait blocks until all function calls from the Go method have returned, then
-	// returns the first non-nil error (if any) from them.
+	// returns the first non-nil error (if any) from them. Wait also waits for any
+	// queued functions (see Submit) to start and return.
+	//
+	// If any function returned a non-fatal error (see GoOpts), Wait instead returns a
+	// *MultiError holding both the first fatal error, if any, and all non-fatal errors.
 	Wait() error
 	// TryGo calls the given function in a new goroutine only if the number of
 	// active goroutines in the group is currently below the configured limit.
@@ -54,4 +61,65 @@ type ContextGroup interface {
 	// The return value reports whether the goroutine was started.
 	// TryGo returns true immediately if the group context is already cancelled.
 	TryGo(func(context.Context) error) bool
+	// GoWithTimeout is like Go, but the function receives a context derived from the
+	// group context which expires after timeout, overriding any default task timeout.
+	// A non-positive timeout indicates no timeout.
+	//
+	// If the function returns a non-nil error after its own timeout has expired, the
+	// error is wrapped in a *TaskTimeoutError identifying the task.
+	GoWithTimeout(timeout time.Duration, f func(context.Context) error)
+	// GoWeighted is like Go, but the task consumes the given weight under the group's
+	// weighted limit (see [WithWeightedLimit]), blocking until enough capacity is free.
+	// Waiters are admitted in FIFO order, so a heavy task is not starved by lighter
+	// tasks submitted after it. If the group has no weighted limit, weight is ignored.
+	//
+	// A weight greater than the group's capacity blocks until the capacity grows (see
+	// [Limiter.Resize]) or the group context is cancelled.
+	GoWeighted(weight int64, f func(context.Context) error)
+	// GoRetry is like Go, but the function is retried according to the given policy
+	// (see [Retry]) before its error is reported to the group. Any task timeout applies
+	// to all attempts together.
+	GoRetry(p Policy, f func(context.Context) error)
+	// GoOpts is like Go, but the task is configured by the given options, e.g.
+	// [NonFatal] to record its errors without cancelling the group context.
+	GoOpts(f func(context.Context) error, opts ...TaskOption)
+	// Submit enqueues the given function to be called in a new goroutine, without
+	// blocking. Queued functions are started in descending order of priority, and in
+	// submission order within the same priority, as the group's limits allow.
+	//
+	// Submit returns ErrQueueFull if the function cannot start immediately and the queue
+	// (see [WithQueue]) is already full, ErrShutdown once Shutdown has begun, or the
+	// group context's error if it is cancelled. Queued functions are discarded if the
+	// group context is cancelled, but still run during a graceful Shutdown.
+	Submit(priority int, f func(context.Context) error) error
+	// Shutdown gracefully stops the group. Once Shutdown is called, the group stops
+	// accepting new work: Go returns immediately and TryGo returns true, without calling
+	// the function or recording an error.
+	//
+	// Running tasks are signaled through the channel returned by [Draining], but the group
+	// context remains live until all tasks return or ctx is done, whichever is first.
+	// If all tasks return, Shutdown returns the same result as Wait. Otherwise, the group
+	// context is cancelled and Shutdown returns a *DrainTimeoutError listing the tasks
+	// which were still running, without waiting for them; call Wait to do so.
+	//
+	// Shutdown also drains all child groups (see Child).
+	Shutdown(ctx context.Context) error
+	// Child creates a new, unlimited sub-group whose context derives from the group
+	// context, so cancelling the group also cancels the child.
+	//
+	// Tasks of the child are also outstanding tasks of the group: the group's Wait
+	// waits for them too. The child's first error propagates upward, cancelling the
+	// group, wrapped in a *GroupError whose Path names the groups involved; the same
+	// *GroupError is the cause of the group context (see [context.Cause]).
+	//
+	// Call Wait on the child to collect its own result, and to release it from the group.
+	Child(name string) ContextGroup
+	// Status returns a snapshot of the group's progress, without waiting, e.g. for
+	// health endpoints. Counts only include the group's own tasks, not those of any
+	// child groups.
+	Status() Status
+	// Done returns a channel which is closed when the group records its first fatal
+	// error, i.e. once Status().Err is non-nil. Unlike the group context, it is not closed
+	// when the group finishes successfully.
+	Done() <-chan struct{}
 }
@@ -0,0 +1,159 @@
+// Package errgrouptest provides utilities for testing code which uses package errgroup.
+//
+// It detects goroutines which were started by package errgroup, e.g. via [errgroup.ContextGroup.Go],
+// and are still running when they should not be. Detection is process-wide, so it should not be used
+// from tests which run in parallel with other tests that use errgroup.
+package errgrouptest
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// DefaultTimeout is how long a Checker waits for goroutines to exit before reporting them.
+const DefaultTimeout = time.Second
+
+const errgroupPrefix = "github.com/fullstorydev/go/errgroup."
+
+// Goroutine describes a running goroutine started by package errgroup.
+type Goroutine struct {
+	// ID is the goroutine id.
+	ID uint64
+	// CreatedBy is the name of the errgroup function which started the goroutine.
+	CreatedBy string
+	// Stack is the goroutine's stack trace, as formatted by the runtime.
+	Stack string
+}
+
+// Running returns all currently running goroutines which were started by package errgroup.
+func Running() []Goroutine {
+	var ret []Goroutine
+	for _, block := range strings.Split(string(allStacks()), "\n\n") {
+		if g, ok := parseGoroutine(block); ok {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// Checker reports errgroup goroutines which were started after it was created, and which are
+// still running when checked.
+type Checker struct {
+	// Timeout is how long Check waits for goroutines to exit before reporting them.
+	Timeout time.Duration
+
+	t        testing.TB
+	baseline map[uint64]bool
+	failed   bool
+}
+
+// VerifyNoLeaks returns a new Checker for t, and registers it to Check when t and its subtests complete.
+func VerifyNoLeaks(t testing.TB) *Checker {
+	c := &Checker{
+		Timeout:  DefaultTimeout,
+		t:        t,
+		baseline: map[uint64]bool{},
+	}
+	for _, g := range Running() {
+		c.baseline[g.ID] = true
+	}
+	t.Cleanup(c.Check)
+	return c
+}
+
+// Wait calls g.Wait(), then calls Check. It returns the result of g.Wait().
+//
+// g is typically an *errgroup.Group or errgroup.ContextGroup.
+func (c *Checker) Wait(g interface{ Wait() error }) error {
+	c.t.Helper()
+	err := g.Wait()
+	c.Check()
+	return err
+}
+
+// Check waits up to Timeout for new errgroup goroutines to exit, then fails the test, reporting
+// the stacks of any which are still running. Once a Checker has failed, subsequent calls do nothing.
+func (c *Checker) Check() {
+	c.t.Helper()
+	if c.failed {
+		return
+	}
+
+	deadline := time.Now().Add(c.Timeout)
+	delay := time.Millisecond
+	for {
+		leaked := c.leaked()
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			c.failed = true
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "errgrouptest: %d goroutines started by errgroup are still running after %s:", len(leaked), c.Timeout)
+			for _, g := range leaked {
+				fmt.Fprintf(&sb, "\n\n%s", g.Stack)
+			}
+			c.t.Error(sb.String())
+			return
+		}
+		time.Sleep(delay)
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+func (c *Checker) leaked() []Goroutine {
+	var ret []Goroutine
+	for _, g := range Running() {
+		if !c.baseline[g.ID] {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// allStacks returns the formatted stack traces of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parseGoroutine parses a single goroutine's stack trace, returning ok only if it was started by errgroup.
+func parseGoroutine(block string) (Goroutine, bool) {
+	block = strings.TrimSpace(block)
+	header, _, _ := strings.Cut(block, "\n")
+	idStr, ok := strings.CutPrefix(header, "goroutine ")
+	if !ok {
+		return Goroutine{}, false
+	}
+	idStr, _, _ = strings.Cut(idStr, " ")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return Goroutine{}, false
+	}
+
+	for _, line := range strings.Split(block, "\n") {
+		createdBy, ok := strings.CutPrefix(line, "created by ")
+		if !ok {
+			continue
+		}
+		// Newer runtimes append the parent goroutine, e.g. " in goroutine 7".
+		createdBy, _, _ = strings.Cut(createdBy, " ")
+		if !strings.HasPrefix(createdBy, errgroupPrefix) {
+			return Goroutine{}, false
+		}
+		return Goroutine{ID: id, CreatedBy: createdBy, Stack: block}, true
+	}
+	return Goroutine{}, false
+}
@@ -0,0 +1,117 @@
+package errgrouptest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+	"github.com/fullstorydev/go/errgroup/errgrouptest"
+)
+
+// fakeT records errors rather than failing the real test.
+type fakeT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Error(args ...any) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *fakeT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *fakeT) runCleanups() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	ft := &fakeT{TB: t}
+	c := errgrouptest.VerifyNoLeaks(ft)
+
+	g := errgroup.New(context.Background())
+	for i := 0; i < 10; i++ {
+		g.Go(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+	if err := c.Wait(g); err != nil {
+		t.Fatal(err)
+	}
+	ft.runCleanups()
+	if len(ft.errors) != 0 {
+		t.Fatalf("unexpected errors: %v", ft.errors)
+	}
+}
+
+func stuckTask(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestVerifyNoLeaksReportsStacks(t *testing.T) {
+	ft := &fakeT{TB: t}
+	c := errgrouptest.VerifyNoLeaks(ft)
+	c.Timeout = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g := errgroup.New(ctx)
+	g.Go(stuckTask)
+
+	ft.runCleanups()
+	if len(ft.errors) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(ft.errors), ft.errors)
+	}
+	msg := ft.errors[0]
+	if !strings.Contains(msg, "1 goroutines started by errgroup are still running") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+	if !strings.Contains(msg, "errgrouptest_test.stuckTask") {
+		t.Errorf("expected stack of stuckTask: %s", msg)
+	}
+
+	// Subsequent checks do not report again.
+	c.Check()
+	if len(ft.errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(ft.errors))
+	}
+
+	cancel()
+	_ = g.Wait()
+}
+
+func TestRunning(t *testing.T) {
+	release := make(chan struct{})
+	var g errgroup.Group
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+
+	var found bool
+	for _, gr := range errgrouptest.Running() {
+		if strings.HasPrefix(gr.CreatedBy, "github.com/fullstorydev/go/errgroup.(*Group).Go") {
+			found = true
+			if gr.ID == 0 || !strings.HasPrefix(gr.Stack, "goroutine ") {
+				t.Errorf("unexpected goroutine: %+v", gr)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected to find goroutine created by Group.Go")
+	}
+
+	close(release)
+	_ = g.Wait()
+}
@@ -0,0 +1,115 @@
+package errgroup
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter limits the total weight of tasks admitted to one or more ContextGroups, and may be
+// resized at any time. Waiters are admitted in FIFO order. See [WithLimiter].
+type Limiter struct {
+	w *weighted
+}
+
+// NewLimiter returns a new Limiter with the given limit.
+func NewLimiter(limit int64) *Limiter {
+	return &Limiter{w: newWeighted(limit)}
+}
+
+// Resize changes the limit. Growing the limit immediately admits waiting tasks which now fit.
+// Shrinking the limit does not interrupt running tasks; new tasks are admitted once enough of the
+// running tasks have finished.
+func (l *Limiter) Resize(limit int64) {
+	l.w.resize(limit)
+}
+
+// Limit returns the current limit.
+func (l *Limiter) Limit() int64 {
+	l.w.mu.Lock()
+	defer l.w.mu.Unlock()
+	return l.w.size
+}
+
+// InUse returns the total weight of currently admitted tasks, which may exceed the limit after shrinking.
+func (l *Limiter) InUse() int64 {
+	l.w.mu.Lock()
+	defer l.w.mu.Unlock()
+	return l.w.cur
+}
+
+// AIMD adaptively adjusts a Limiter using additive-increase/multiplicative-decrease, based on the
+// latency and errors of completed tasks. See [WithAIMD].
+//
+// After each window of successful tasks, where a window is as many tasks as the current limit,
+// the limit grows by Increase. When a task fails or exceeds LatencyTarget, the limit is multiplied
+// by Decrease, at most once per window.
+type AIMD struct {
+	// Limiter is the limiter adjusted by this controller.
+	Limiter *Limiter
+	// Min is the lower bound for the limit. Values less than 1 are treated as 1.
+	Min int64
+	// Max is the upper bound for the limit. Zero means no bound.
+	Max int64
+	// Increase is added to the limit after each window of successes. Zero means 1.
+	Increase int64
+	// Decrease multiplies the limit after a failure, in (0, 1). Zero means 0.5.
+	Decrease float64
+	// LatencyTarget is the latency above which a task counts as a failure. Zero means latency is ignored.
+	LatencyTarget time.Duration
+
+	mu            sync.Mutex
+	successes     int64
+	sinceDecrease int64
+	decreased     bool
+}
+
+// Observe records the outcome of a completed task, adjusting the limit as needed.
+func (a *AIMD) Observe(latency time.Duration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limit := a.Limiter.Limit()
+	a.sinceDecrease++
+	if err != nil || (a.LatencyTarget > 0 && latency > a.LatencyTarget) {
+		a.successes = 0
+		if a.decreased && a.sinceDecrease < limit {
+			return // tasks admitted before the last decrease are still completing
+		}
+		decrease := a.Decrease
+		if decrease <= 0 || decrease >= 1 {
+			decrease = 0.5
+		}
+		a.Limiter.Resize(a.clamp(int64(float64(limit) * decrease)))
+		a.sinceDecrease = 0
+		a.decreased = true
+		return
+	}
+
+	a.successes++
+	if a.successes >= limit {
+		a.successes = 0
+		increase := a.Increase
+		if increase <= 0 {
+			increase = 1
+		}
+		a.Limiter.Resize(a.clamp(limit + increase))
+	}
+}
+
+func (a *AIMD) clamp(limit int64) int64 {
+	lo, hi := a.Min, a.Max
+	if lo < 1 {
+		lo = 1
+	}
+	if hi <= 0 {
+		hi = math.MaxInt64
+	}
+	if limit < lo {
+		return lo
+	}
+	if limit > hi {
+		return hi
+	}
+	return limit
+}
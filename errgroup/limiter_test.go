@@ -0,0 +1,138 @@
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterResize(t *testing.T) {
+	l := NewLimiter(1)
+	g := WithLimiter(l).New(context.Background())
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	task := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	g.Go(task)
+	<-started
+	go g.Go(task)
+	go g.Go(task)
+	waitForWaiters(t, l.w, 2)
+
+	// Growing admits waiters immediately.
+	l.Resize(3)
+	<-started
+	<-started
+	if got := l.InUse(); got != 3 {
+		t.Errorf("got %d in use, want 3", got)
+	}
+
+	// Shrinking lets running tasks finish, but admits nothing new until below the limit.
+	l.Resize(1)
+	if g.TryGo(task) {
+		t.Error("TryGo should fail after shrinking")
+	}
+	if got := l.Limit(); got != 1 {
+		t.Errorf("got limit %d, want 1", got)
+	}
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.InUse(); got != 0 {
+		t.Errorf("got %d in use, want 0", got)
+	}
+}
+
+func TestLimiterShared(t *testing.T) {
+	l := NewLimiter(1)
+	g1 := WithLimiter(l).New(context.Background())
+	g2 := WithLimiter(l).New(context.Background())
+
+	release := make(chan struct{})
+	g1.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if g2.TryGo(func(ctx context.Context) error { return nil }) {
+		t.Error("TryGo should fail while the shared limiter is full")
+	}
+	close(release)
+	if err := g1.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !g2.TryGo(func(ctx context.Context) error { return nil }) {
+		t.Error("TryGo should succeed once the shared limiter is free")
+	}
+	if err := g2.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	errDoom := errors.New("limiter_test: doomed")
+	a := &AIMD{
+		Limiter:       NewLimiter(4),
+		Min:           2,
+		Max:           6,
+		LatencyTarget: time.Second,
+	}
+
+	// Additive increase after each window of successes.
+	for i := 0; i < 4; i++ {
+		a.Observe(time.Millisecond, nil)
+	}
+	if got := a.Limiter.Limit(); got != 5 {
+		t.Fatalf("got limit %d, want 5", got)
+	}
+	for i := 0; i < 10; i++ {
+		a.Observe(time.Millisecond, nil)
+	}
+	if got := a.Limiter.Limit(); got != 6 {
+		t.Fatalf("got limit %d, want max 6", got)
+	}
+
+	// Multiplicative decrease, at most once per window.
+	a.Observe(time.Millisecond, errDoom)
+	if got := a.Limiter.Limit(); got != 3 {
+		t.Fatalf("got limit %d, want 3", got)
+	}
+	a.Observe(2*time.Second, nil)
+	if got := a.Limiter.Limit(); got != 3 {
+		t.Fatalf("got limit %d, want 3", got)
+	}
+	a.Observe(time.Millisecond, nil)
+	a.Observe(2*time.Second, nil) // too slow
+	if got := a.Limiter.Limit(); got != 2 {
+		t.Fatalf("got limit %d, want min 2", got)
+	}
+}
+
+func TestWithAIMD(t *testing.T) {
+	a := &AIMD{Limiter: NewLimiter(1), Max: 3}
+	g := WithAIMD(a).New(context.Background())
+	for i := 0; i < 10; i++ {
+		g.Go(func(ctx context.Context) error { return nil })
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Limiter.Limit(); got != 3 {
+		t.Fatalf("got limit %d, want 3", got)
+	}
+
+	g = WithAIMD(a).New(context.Background())
+	g.Go(func(ctx context.Context) error { panic("test panic") })
+	var pe *PanicError
+	if err := g.Wait(); !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+	if got := a.Limiter.Limit(); got != 1 {
+		t.Fatalf("got limit %d, want 1", got)
+	}
+}
@@ -0,0 +1,119 @@
+package errgroup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskOption configures a single task started via [ContextGroup.GoOpts].
+type TaskOption func(*taskConfig)
+
+// NonFatal marks a task's errors as non-fatal: they are recorded, and reported by Wait, but do not
+// cancel the group. Panics are always fatal.
+func NonFatal() TaskOption {
+	return func(cfg *taskConfig) {
+		cfg.nonFatal = func(error) bool { return true }
+	}
+}
+
+// Fatal marks a task's errors as fatal, regardless of the group's classifier (see [WithNonFatal]).
+func Fatal() TaskOption {
+	return func(cfg *taskConfig) {
+		cfg.nonFatal = nil
+	}
+}
+
+// Timeout gives a task a context which expires after timeout, as with [ContextGroup.GoWithTimeout].
+func Timeout(timeout time.Duration) TaskOption {
+	return func(cfg *taskConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// Weight makes a task consume the given weight under the group's weighted limit, as with
+// [ContextGroup.GoWeighted].
+func Weight(weight int64) TaskOption {
+	return func(cfg *taskConfig) {
+		cfg.weight = weight
+	}
+}
+
+// GoOpts calls the given function in a new goroutine, configured by opts.
+func (g *ctxGroup) GoOpts(f func(context.Context) error, opts ...TaskOption) {
+	cfg := g.newTaskConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	g.submit(cfg, f)
+}
+
+// TaskError is a non-fatal error returned by a task.
+type TaskError struct {
+	// Task identifies the task which failed.
+	Task Task
+	// Err is the error returned by the task.
+	Err error
+}
+
+var _ error = (*TaskError)(nil)
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Task, e.Err)
+}
+
+// Unwrap returns the error returned by the task.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned by [ContextGroup.Wait] when any task failed with a non-fatal error.
+type MultiError struct {
+	// Fatal is the first fatal error, which cancelled the group, or nil if there was none.
+	Fatal error
+	// NonFatal lists the non-fatal errors, in the order they were recorded.
+	NonFatal []*TaskError
+}
+
+var _ error = (*MultiError)(nil)
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.NonFatal))
+	for i, te := range e.NonFatal {
+		msgs[i] = te.Error()
+	}
+	if e.Fatal != nil {
+		return fmt.Sprintf("%v (and %d non-fatal errors: %s)", e.Fatal, len(e.NonFatal), strings.Join(msgs, "; "))
+	}
+	return fmt.Sprintf("errgroup: %d non-fatal errors: %s", len(e.NonFatal), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the fatal error, if any, followed by each non-fatal error.
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(e.NonFatal)+1)
+	if e.Fatal != nil {
+		errs = append(errs, e.Fatal)
+	}
+	for _, te := range e.NonFatal {
+		errs = append(errs, te)
+	}
+	return errs
+}
+
+// nonFatalError records a non-fatal error from the given task.
+func (g *ctxGroup) nonFatalError(task Task, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.errs = append(g.errs, &TaskError{Task: task, Err: err})
+}
+
+// result returns the error for Wait to return.
+func (g *ctxGroup) result() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return g.err
+	}
+	return &MultiError{Fatal: g.err, NonFatal: append([]*TaskError(nil), g.errs...)}
+}
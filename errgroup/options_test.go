@@ -0,0 +1,129 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestGoOptsNonFatal(t *testing.T) {
+	errSkip := errors.New("options_test: skipped")
+	g := errgroup.New(context.Background())
+	g.GoOpts(func(ctx context.Context) error {
+		return errSkip
+	}, errgroup.NonFatal())
+
+	var sibling error
+	g.Go(func(ctx context.Context) error {
+		for g.Status().NonFatal == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		sibling = ctx.Err()
+		return nil
+	})
+
+	err := g.Wait()
+	if sibling != nil {
+		t.Errorf("non-fatal error cancelled sibling: %v", sibling)
+	}
+	var me *errgroup.MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("expected multi error, got: %v", err)
+	}
+	if me.Fatal != nil {
+		t.Errorf("unexpected fatal error: %v", me.Fatal)
+	}
+	if len(me.NonFatal) != 1 || me.NonFatal[0].Err != errSkip || me.NonFatal[0].Task.ID != 1 {
+		t.Fatalf("got non-fatal errors: %v, want: [%v]", me.NonFatal, errSkip)
+	}
+	if !errors.Is(err, errSkip) {
+		t.Errorf("expected multi error to wrap %v", errSkip)
+	}
+	if want := "errgroup: 1 non-fatal errors: " + me.NonFatal[0].Error(); err.Error() != want {
+		t.Errorf("got: %q, want: %q", err.Error(), want)
+	}
+	if st := g.Status(); st.Failed != 1 || st.NonFatal != 1 || st.Err != nil {
+		t.Errorf("got status: %+v", st)
+	}
+}
+
+func TestWithNonFatal(t *testing.T) {
+	errSkip := errors.New("options_test: skipped")
+	errDoom := errors.New("options_test: doomed")
+	g := errgroup.WithNonFatal(func(err error) bool {
+		return errors.Is(err, errSkip)
+	}).New(context.Background())
+
+	g.Go(func(ctx context.Context) error { return errSkip })
+	for g.Status().NonFatal == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-g.Done():
+		t.Fatal("non-fatal error closed Done")
+	default:
+	}
+
+	// Fatal overrides the classifier.
+	g.GoOpts(func(ctx context.Context) error {
+		return errors.Join(errSkip, errDoom)
+	}, errgroup.Fatal())
+	<-g.Done()
+
+	err := g.Wait()
+	var me *errgroup.MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("expected multi error, got: %v", err)
+	}
+	if !errors.Is(me.Fatal, errDoom) {
+		t.Errorf("got fatal error: %v, want: %v", me.Fatal, errDoom)
+	}
+	if len(me.NonFatal) != 1 || me.NonFatal[0].Err != errSkip {
+		t.Errorf("got non-fatal errors: %v, want: [%v]", me.NonFatal, errSkip)
+	}
+	if !errors.Is(err, errDoom) || !errors.Is(err, errSkip) {
+		t.Errorf("expected multi error to wrap both errors, got: %v", err)
+	}
+}
+
+func TestNonFatalPanic(t *testing.T) {
+	g := errgroup.WithNonFatal(func(error) bool { return true }).New(context.Background())
+	g.GoOpts(func(ctx context.Context) error {
+		panic("options_test: panic")
+	}, errgroup.NonFatal())
+
+	err := g.Wait()
+	var pe *errgroup.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+	var me *errgroup.MultiError
+	if errors.As(err, &me) {
+		t.Errorf("panic was recorded as non-fatal: %v", err)
+	}
+}
+
+func TestGoOptsTimeout(t *testing.T) {
+	g := errgroup.WithWeightedLimit(2).New(context.Background())
+	g.GoOpts(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, errgroup.Timeout(time.Millisecond), errgroup.Weight(2))
+
+	var te *errgroup.TaskTimeoutError
+	if err := g.Wait(); !errors.As(err, &te) {
+		t.Fatalf("expected task timeout error, got: %v", err)
+	}
+}
+
+func TestNoNonFatalErrors(t *testing.T) {
+	errDoom := errors.New("options_test: doomed")
+	g := errgroup.WithNonFatal(func(error) bool { return false }).New(context.Background())
+	g.Go(func(ctx context.Context) error { return errDoom })
+	if err := g.Wait(); err != errDoom {
+		t.Fatalf("got: %v, want: %v", err, errDoom)
+	}
+}
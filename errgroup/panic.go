@@ -2,8 +2,11 @@ package errgroup
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -13,7 +16,8 @@ const (
 )
 
 var (
-	newline = []byte{'\n'}
+	goroutinePrefix = []byte("goroutine ")
+	newline         = []byte{'\n'}
 )
 
 // NewPanicError creates a new PanicError with the given recovered panic value.
@@ -31,29 +35,68 @@ func NewPanicErrorCallers(recovered any, skip int) *PanicError {
 	pcs := make([]uintptr, pcFrames)
 	pcs = pcs[:runtime.Callers(skip+1, pcs)]
 
-	// Manually prune the string stack trace based on skip (this is awkward).
-	skipLines := 1 + 2*skip
+	// Expand the pcs into frames; this correctly accounts for inlined calls.
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if frame.PC != 0 {
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+
 	stack := make([]byte, stackSize)
 	stack = stack[:runtime.Stack(stack, false)]
-	var sb strings.Builder
-	for i, line := range bytes.Split(stack, newline) {
-		if i == 0 || i >= skipLines {
-			sb.Write(line)
-			sb.WriteByte('\n')
+	goroutine, trace := pruneStack(stack, frames)
+	return &PanicError{recovered: recovered, goroutine: goroutine, pcs: pcs, frames: frames, stack: trace}
+}
+
+// pruneStack parses the goroutine id out of a trace from runtime.Stack, and drops the frames above
+// the first of the given frames, keeping the runtime's format. If that frame cannot be found, the
+// whole trace is kept.
+func pruneStack(stack []byte, frames []runtime.Frame) (uint64, string) {
+	lines := bytes.Split(bytes.TrimSuffix(stack, newline), newline)
+	goroutine := parseGoroutineID(lines[0])
+	if len(frames) > 0 {
+		// After the header, each frame is a function line followed by an indented file:line line.
+		name := frames[0].Function
+		if name == "runtime.gopanic" {
+			name = "panic" // as the runtime prints it
+		}
+		prefix := []byte(name + "(")
+		for i := 1; i < len(lines); i += 2 {
+			if bytes.HasPrefix(lines[i], prefix) {
+				lines = append(lines[:1], lines[i:]...)
+				break
+			}
 		}
 	}
 
-	return &PanicError{recovered: recovered, pcs: pcs, stack: sb.String()}
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.Write(line)
+		sb.WriteByte('\n')
+	}
+	return goroutine, sb.String()
 }
 
 // PanicError represents a wrapped recovered panic value.
 type PanicError struct {
 	recovered any
+	goroutine uint64
 	pcs       []uintptr
+	frames    []runtime.Frame
 	stack     string
 }
 
-var _ error = (*PanicError)(nil)
+var (
+	_ error          = (*PanicError)(nil)
+	_ fmt.Formatter  = (*PanicError)(nil)
+	_ json.Marshaler = (*PanicError)(nil)
+)
 
 // Recovered returns the original value.
 func (e *PanicError) Recovered() any {
@@ -62,7 +105,7 @@ func (e *PanicError) Recovered() any {
 
 // Error returns the full error, including stack trace.
 func (e *PanicError) Error() string {
-	return fmt.Sprintf("panic: %v [recovered]\n\n%s", e.recovered, e.stack)
+	return fmt.Sprintf("panic: %v [recovered]\n\n%s", e.recovered, e.StackTrace())
 }
 
 // Message returns a short description, with the string value of the recovered object.
@@ -79,12 +122,89 @@ func (e *PanicError) Unwrap() error {
 	return nil
 }
 
+// GoroutineID returns the id of the goroutine which panicked, or 0 if it could not be determined.
+func (e *PanicError) GoroutineID() uint64 {
+	return e.goroutine
+}
+
 // StackFrames returns a slice of program counters composing this error's stacktrace.
 func (e *PanicError) StackFrames() []uintptr {
 	return append([]uintptr{}, e.pcs...)
 }
 
-// StackTrace returns the originally captured stack trace as a multiline string.
+// Frames returns the parsed frames composing this error's stacktrace, innermost first.
+func (e *PanicError) Frames() []runtime.Frame {
+	return append([]runtime.Frame{}, e.frames...)
+}
+
+// StackTrace returns the captured stack trace as a multiline string, in the format produced by
+// the runtime, starting with the first of the Frames.
 func (e *PanicError) StackTrace() string {
 	return e.stack
 }
+
+// Format implements [fmt.Formatter].
+//
+// The %s and %v verbs print the short Message, while %+v prints the full Error, including
+// stack trace. %q prints the short Message, quoted.
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			_, _ = io.WriteString(s, e.Error())
+		case s.Flag('#'):
+			_, _ = fmt.Fprintf(s, "&errgroup.PanicError{recovered:%#v, goroutine:%d}", e.recovered, e.goroutine)
+		default:
+			_, _ = io.WriteString(s, e.Message())
+		}
+	case 's':
+		_, _ = io.WriteString(s, e.Message())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.Message())
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(*errgroup.PanicError=%s)", verb, e.Message())
+	}
+}
+
+// MarshalJSON implements [json.Marshaler], encoding the short message, goroutine id, and stack frames.
+func (e *PanicError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(panicErrorJSON{
+		Message:   e.Message(),
+		Goroutine: e.goroutine,
+		Frames:    e.jsonFrames(),
+	})
+}
+
+type panicErrorJSON struct {
+	Message   string      `json:"message"`
+	Goroutine uint64      `json:"goroutine"`
+	Frames    []jsonFrame `json:"frames"`
+}
+
+type jsonFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (e *PanicError) jsonFrames() []jsonFrame {
+	ret := make([]jsonFrame, len(e.frames))
+	for i, frame := range e.frames {
+		ret[i] = jsonFrame{Function: frame.Function, File: frame.File, Line: frame.Line}
+	}
+	return ret
+}
+
+// parseGoroutineID parses the goroutine id out of the header line of runtime.Stack, returning 0 if it cannot.
+func parseGoroutineID(header []byte) uint64 {
+	header = bytes.TrimPrefix(header, goroutinePrefix)
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, err := strconv.ParseUint(string(header), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
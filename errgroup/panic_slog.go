@@ -0,0 +1,17 @@
+//go:build go1.21
+
+package errgroup
+
+import "log/slog"
+
+var _ slog.LogValuer = (*PanicError)(nil)
+
+// LogValue implements [slog.LogValuer], logging the short message, goroutine id, and stack frames
+// as structured fields.
+func (e *PanicError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Message()),
+		slog.Uint64("goroutine", e.goroutine),
+		slog.Any("frames", e.jsonFrames()),
+	)
+}
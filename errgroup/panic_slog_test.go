@@ -0,0 +1,38 @@
+//go:build go1.21
+
+package errgroup
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestPanicErrorLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Error("task failed", "err", NewPanicError("test panic"))
+
+	var got struct {
+		Err struct {
+			Message   string `json:"message"`
+			Goroutine uint64 `json:"goroutine"`
+			Frames    []struct {
+				Function string `json:"function"`
+			} `json:"frames"`
+		} `json:"err"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Err.Message != "panic: test panic" {
+		t.Errorf("unexpected message: %q", got.Err.Message)
+	}
+	if got.Err.Goroutine == 0 {
+		t.Error("expected goroutine id")
+	}
+	if len(got.Err.Frames) == 0 || got.Err.Frames[0].Function != `github.com/fullstorydev/go/errgroup.TestPanicErrorLogValue` {
+		t.Errorf("unexpected frames: %+v", got.Err.Frames)
+	}
+}
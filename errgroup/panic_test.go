@@ -1,6 +1,7 @@
 package errgroup
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"runtime"
@@ -109,3 +110,109 @@ func TestPanicErrorStackFramesCallers(t *testing.T) {
 		t.Errorf("got:  %q, want: %q", got, want)
 	}
 }
+
+func TestPanicErrorFormat(t *testing.T) {
+	err := NewPanicError("test panic")
+	for _, tc := range []struct {
+		fmt  string
+		want string
+	}{
+		{"%v", `panic: test panic`},
+		{"%s", `panic: test panic`},
+		{"%q", `"panic: test panic"`},
+		{"%+v", err.Error()},
+		{"%d", `%!d(*errgroup.PanicError=panic: test panic)`},
+	} {
+		if got := fmt.Sprintf(tc.fmt, err); got != tc.want {
+			t.Errorf("%s: got:  %q, want: %q", tc.fmt, got, tc.want)
+		}
+	}
+}
+
+func TestPanicErrorFrames(t *testing.T) {
+	err := NewPanicError("test panic")
+	frames := err.Frames()
+	if len(frames) == 0 {
+		t.Fatal("expected frames")
+	}
+	const want = `github.com/fullstorydev/go/errgroup.TestPanicErrorFrames`
+	if got := frames[0].Function; got != want {
+		t.Errorf("got:  %q, want: %q", got, want)
+	}
+	if !strings.HasSuffix(frames[0].File, "errgroup/panic_test.go") {
+		t.Errorf("unexpected file: %s", frames[0].File)
+	}
+	if frames[0].Line == 0 {
+		t.Error("expected line number")
+	}
+}
+
+//go:noinline
+func newPanicErrorSkipping(skip int) *PanicError {
+	return NewPanicErrorCallers("test panic", skip)
+}
+
+func TestPanicErrorFramesSkip(t *testing.T) {
+	for _, tc := range []struct {
+		skip int
+		want string
+	}{
+		{0, `github.com/fullstorydev/go/errgroup.NewPanicErrorCallers`},
+		{1, `github.com/fullstorydev/go/errgroup.newPanicErrorSkipping`},
+		{2, `github.com/fullstorydev/go/errgroup.TestPanicErrorFramesSkip`},
+	} {
+		err := newPanicErrorSkipping(tc.skip)
+		if got := err.Frames()[0].Function; got != tc.want {
+			t.Errorf("skip %d: got:  %q, want: %q", tc.skip, got, tc.want)
+		}
+		// The string trace must agree with the parsed frames.
+		lines := strings.Split(err.StackTrace(), "\n")
+		if !strings.HasPrefix(lines[1], tc.want+"(") {
+			t.Errorf("skip %d: got:  %q, want: %q", tc.skip, lines[1], tc.want)
+		}
+	}
+}
+
+func TestPanicErrorGoroutineID(t *testing.T) {
+	ids := make(chan uint64, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			ids <- NewPanicError("test panic").GoroutineID()
+		}()
+	}
+	first, second := <-ids, <-ids
+	if first == 0 || second == 0 {
+		t.Fatalf("expected non-zero goroutine ids, got %d and %d", first, second)
+	}
+	if first == second {
+		t.Errorf("expected distinct goroutine ids, got %d twice", first)
+	}
+}
+
+func TestPanicErrorJSON(t *testing.T) {
+	b, err := json.Marshal(NewPanicError("test panic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Message   string `json:"message"`
+		Goroutine uint64 `json:"goroutine"`
+		Frames    []struct {
+			Function string `json:"function"`
+			File     string `json:"file"`
+			Line     int    `json:"line"`
+		} `json:"frames"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "panic: test panic" {
+		t.Errorf("unexpected message: %q", got.Message)
+	}
+	if got.Goroutine == 0 {
+		t.Error("expected goroutine id")
+	}
+	if len(got.Frames) == 0 || got.Frames[0].Function != `github.com/fullstorydev/go/errgroup.TestPanicErrorJSON` {
+		t.Errorf("unexpected frames: %+v", got.Frames)
+	}
+}
@@ -0,0 +1,173 @@
+// Package pipeline runs multi-stage pipelines, e.g. read → transform → write, on top of package errgroup.
+//
+// Each stage runs one or more workers in its own child group (see [errgroup.ContextGroup.Child]) of a
+// shared [errgroup.ContextGroup], and stages are connected by typed, bounded channels. A stage's output
+// channel is closed once all of its workers have returned, which ends the workers of the next stage.
+// Any error or panic cancels every stage; all workers stop sending and receiving, so nothing deadlocks.
+//
+// Errors from a stage are reported by [Pipeline.Wait] wrapped in an [*errgroup.GroupError] whose Path
+// ends with the stage name, e.g. "errgroup: group parse: panic: ..." for a *errgroup.PanicError.
+//
+// Every stage's output must be consumed by a later stage, e.g. a [Sink]; otherwise the pipeline
+// blocks once the output channel's buffer is full.
+package pipeline
+
+import (
+	"context"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+// Pipeline is a set of connected stages sharing a single group.
+type Pipeline struct {
+	g errgroup.ContextGroup
+}
+
+// New returns a new Pipeline whose stages run in a group derived from ctx.
+func New(ctx context.Context) *Pipeline {
+	return &Pipeline{g: errgroup.New(ctx)}
+}
+
+// Wait blocks until all stages have returned, then returns the first error (if any) from them.
+func (p *Pipeline) Wait() error {
+	return p.g.Wait()
+}
+
+// Stage is the output of a pipeline stage, which may be consumed by exactly one later stage.
+type Stage[T any] struct {
+	name string
+	out  chan T
+}
+
+// Name returns the name of the stage.
+func (s *Stage[T]) Name() string {
+	return s.name
+}
+
+// Option configures a stage.
+type Option func(*stageConfig)
+
+type stageConfig struct {
+	workers int
+	buffer  int
+}
+
+// Workers sets the number of workers which run a stage concurrently. The default is 1.
+func Workers(n int) Option {
+	return func(cfg *stageConfig) {
+		cfg.workers = n
+	}
+}
+
+// Buffer sets the capacity of a stage's output channel. The default is 0, i.e. unbuffered.
+func Buffer(n int) Option {
+	return func(cfg *stageConfig) {
+		cfg.buffer = n
+	}
+}
+
+func newStageConfig(opts []Option) stageConfig {
+	cfg := stageConfig{workers: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	return cfg
+}
+
+// start runs worker in cfg.workers goroutines of a new child group, and closes out (if non-nil)
+// once they have all returned.
+func (p *Pipeline) start(name string, cfg stageConfig, closeOut func(), worker func(context.Context) error) {
+	stage := p.g.Child(name)
+	for i := 0; i < cfg.workers; i++ {
+		stage.Go(worker)
+	}
+	p.g.Go(func(ctx context.Context) error {
+		err := stage.Wait()
+		if closeOut != nil {
+			closeOut()
+		}
+		// Any error from the stage itself has already propagated; this only reports
+		// cancellation from outside the pipeline.
+		return err
+	})
+}
+
+// Source starts a stage which produces values by calling emit. Source runs fn in a single worker,
+// regardless of [Workers]. The function emit blocks until the value is sent, or returns the context's
+// error if the pipeline is cancelled first.
+func Source[T any](p *Pipeline, name string, fn func(ctx context.Context, emit func(T) error) error, opts ...Option) *Stage[T] {
+	cfg := newStageConfig(opts)
+	cfg.workers = 1
+	s := &Stage[T]{name: name, out: make(chan T, cfg.buffer)}
+	p.start(name, cfg, func() { close(s.out) }, func(ctx context.Context) error {
+		return fn(ctx, emitter(ctx, s.out))
+	})
+	return s
+}
+
+// Map starts a stage which transforms each value from in into exactly one output value.
+func Map[In, Out any](p *Pipeline, name string, in *Stage[In], fn func(context.Context, In) (Out, error), opts ...Option) *Stage[Out] {
+	return FlatMap(p, name, in, func(ctx context.Context, v In, emit func(Out) error) error {
+		r, err := fn(ctx, v)
+		if err != nil {
+			return err
+		}
+		return emit(r)
+	}, opts...)
+}
+
+// FlatMap starts a stage which transforms each value from in into any number of output values,
+// by calling emit.
+func FlatMap[In, Out any](p *Pipeline, name string, in *Stage[In], fn func(ctx context.Context, v In, emit func(Out) error) error, opts ...Option) *Stage[Out] {
+	cfg := newStageConfig(opts)
+	s := &Stage[Out]{name: name, out: make(chan Out, cfg.buffer)}
+	p.start(name, cfg, func() { close(s.out) }, func(ctx context.Context) error {
+		emit := emitter(ctx, s.out)
+		return receive(ctx, in, func(v In) error {
+			return fn(ctx, v, emit)
+		})
+	})
+	return s
+}
+
+// Sink starts a final stage which consumes each value from in.
+func Sink[In any](p *Pipeline, name string, in *Stage[In], fn func(context.Context, In) error, opts ...Option) {
+	cfg := newStageConfig(opts)
+	p.start(name, cfg, nil, func(ctx context.Context) error {
+		return receive(ctx, in, func(v In) error {
+			return fn(ctx, v)
+		})
+	})
+}
+
+// emitter returns a function which sends values to out until ctx is done.
+func emitter[T any](ctx context.Context, out chan<- T) func(T) error {
+	return func(v T) error {
+		select {
+		case out <- v:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// receive calls fn with each value from in, until in is closed, fn fails, or ctx is done.
+func receive[T any](ctx context.Context, in *Stage[T], fn func(T) error) error {
+	for {
+		select {
+		case v, ok := <-in.out:
+			if !ok {
+				return nil
+			}
+			if err := fn(v); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fullstorydev/go/errgroup"
+	"github.com/fullstorydev/go/errgroup/errgrouptest"
+	"github.com/fullstorydev/go/errgroup/pipeline"
+)
+
+// count emits 1 to n, or forever if n is negative.
+func count(n int) func(ctx context.Context, emit func(int) error) error {
+	return func(ctx context.Context, emit func(int) error) error {
+		for i := 1; n < 0 || i <= n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func square(ctx context.Context, v int) (int, error) {
+	return v * v, nil
+}
+
+func TestPipeline(t *testing.T) {
+	leaks := errgrouptest.VerifyNoLeaks(t)
+	p := pipeline.New(context.Background())
+	nums := pipeline.Source(p, "count", count(100), pipeline.Buffer(8))
+	squares := pipeline.Map(p, "square", nums, square, pipeline.Workers(4), pipeline.Buffer(8))
+
+	sum := 0
+	pipeline.Sink(p, "sum", squares, func(ctx context.Context, v int) error {
+		sum += v
+		return nil
+	})
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if want := 338350; sum != want {
+		t.Errorf("got: %d, want: %d", sum, want)
+	}
+	leaks.Check()
+}
+
+func TestFlatMap(t *testing.T) {
+	p := pipeline.New(context.Background())
+	lines := pipeline.Source(p, "read", func(ctx context.Context, emit func(string) error) error {
+		for _, line := range []string{"a b", "", "c"} {
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	words := pipeline.FlatMap(p, "split", lines, func(ctx context.Context, line string, emit func(string) error) error {
+		for _, w := range strings.Fields(line) {
+			if err := emit(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, pipeline.Workers(2))
+
+	var mu sync.Mutex
+	var got []string
+	pipeline.Sink(p, "collect", words, func(ctx context.Context, w string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, w)
+		return nil
+	}, pipeline.Workers(2))
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if words.Name() != "split" {
+		t.Errorf("got name: %q, want: %q", words.Name(), "split")
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	errDoom := errors.New("pipeline_test: doomed")
+	leaks := errgrouptest.VerifyNoLeaks(t)
+	p := pipeline.New(context.Background())
+	nums := pipeline.Source(p, "count", count(-1))
+	squares := pipeline.Map(p, "square", nums, func(ctx context.Context, v int) (int, error) {
+		if v == 50 {
+			return 0, errDoom
+		}
+		return v * v, nil
+	}, pipeline.Workers(4))
+	pipeline.Sink(p, "discard", squares, func(ctx context.Context, v int) error {
+		return nil
+	})
+
+	err := p.Wait()
+	var ge *errgroup.GroupError
+	if !errors.As(err, &ge) {
+		t.Fatalf("expected group error, got: %v", err)
+	}
+	if want := []string{"square"}; !reflect.DeepEqual(ge.Path, want) {
+		t.Errorf("got path: %q, want: %q", ge.Path, want)
+	}
+	if !errors.Is(err, errDoom) {
+		t.Errorf("expected error to wrap %v", errDoom)
+	}
+	leaks.Check()
+}
+
+func TestPipelinePanic(t *testing.T) {
+	p := pipeline.New(context.Background())
+	nums := pipeline.Source(p, "count", count(-1))
+	pipeline.Sink(p, "explode", nums, func(ctx context.Context, v int) error {
+		panic("pipeline_test: panic")
+	})
+
+	err := p.Wait()
+	var pe *errgroup.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+	if want := "errgroup: group explode: panic: pipeline_test: panic"; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got: %q, want prefix: %q", err.Error(), want)
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := pipeline.New(ctx)
+	nums := pipeline.Source(p, "count", count(-1))
+	pipeline.Sink(p, "cancel", nums, func(ctx context.Context, v int) error {
+		if v == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if err := p.Wait(); err != context.Canceled {
+		t.Fatalf("got: %v, want: %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,151 @@
+package errgroup
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by [ContextGroup.Submit] when the group's queue is full.
+	ErrQueueFull = errors.New("errgroup: queue is full")
+	// ErrShutdown is returned by [ContextGroup.Submit] once [ContextGroup.Shutdown] has begun.
+	ErrShutdown = errors.New("errgroup: group is shutting down")
+)
+
+// queuedTask is a task waiting in a taskQueue.
+type queuedTask struct {
+	priority int
+	seq      int64 // submission order, for FIFO within a priority
+	cfg      taskConfig
+	f        func(context.Context) error
+}
+
+// taskQueue is a heap of queued tasks, ordered by descending priority, then ascending seq.
+type taskQueue []queuedTask
+
+var _ heap.Interface = (*taskQueue)(nil)
+
+func (q taskQueue) Len() int { return len(q) }
+
+func (q taskQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q taskQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *taskQueue) Push(x any) { *q = append(*q, x.(queuedTask)) }
+
+func (q *taskQueue) Pop() any {
+	old := *q
+	n := len(old) - 1
+	item := old[n]
+	old[n] = queuedTask{} // release references
+	*q = old[:n]
+	return item
+}
+
+// Submit enqueues the given function without blocking.
+func (g *ctxGroup) Submit(priority int, f func(context.Context) error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.draining {
+		return ErrShutdown
+	}
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+
+	// Queued tasks are outstanding, as far as Wait is concerned.
+	if !g.trackLocked() {
+		return ErrShutdown // an ancestor is shutting down
+	}
+
+	cfg := g.newTaskConfig()
+	if len(g.queue) == 0 {
+		// Nothing to wait behind; start right away if possible.
+		if ok, _ := g.tryAcquire(cfg); ok {
+			g.launchLocked(cfg, f)
+			return nil
+		}
+	}
+	if len(g.queue) >= g.maxQueue {
+		g.untrack()
+		return ErrQueueFull
+	}
+
+	g.queueSeq++
+	heap.Push(&g.queue, queuedTask{priority: priority, seq: g.queueSeq, cfg: cfg, f: f})
+	if !g.watching {
+		g.watching = true
+		var freed chan struct{}
+		if g.weights != nil {
+			// Watch before dispatching, so that no weight freed from now on is missed.
+			freed = make(chan struct{}, 1)
+			g.weights.watch(freed)
+		}
+		go g.watchQueue(freed)
+	}
+	g.dispatchLocked()
+	return nil
+}
+
+// watchQueue dispatches queued tasks when weight under the group's weighted limit is freed by
+// anything other than the group's own tasks, such as another group sharing its Limiter, or a
+// Resize; and discards them once the group context is done. freed is nil if the group has no
+// weighted limit.
+func (g *ctxGroup) watchQueue(freed chan struct{}) {
+	if freed != nil {
+		defer g.weights.unwatch(freed)
+	}
+	for {
+		select {
+		case <-freed:
+		case <-g.ctx.Done():
+		}
+		g.mu.Lock()
+		// While the rate limit holds the queue back, its retry dispatches instead; dispatching here
+		// would take and put back the weight, signalling freed again.
+		if !g.retrying || g.ctx.Err() != nil {
+			g.dispatchLocked()
+		}
+		g.mu.Unlock()
+		if g.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// dispatchLocked starts queued tasks in priority order, for as long as limits allow.
+// If the group context is done, queued tasks are discarded instead. The caller must hold mu.
+func (g *ctxGroup) dispatchLocked() {
+	if g.ctx.Err() != nil {
+		for len(g.queue) > 0 {
+			heap.Pop(&g.queue)
+			g.untrack()
+		}
+		return
+	}
+	for len(g.queue) > 0 {
+		ok, rateLimited := g.tryAcquire(g.queue[0].cfg)
+		if !ok {
+			if rateLimited && !g.retrying {
+				// No task completion is guaranteed to dispatch again, so try again once a token accrues.
+				g.retrying = true
+				time.AfterFunc(time.Duration(float64(time.Second)/g.rate.rate), func() {
+					g.mu.Lock()
+					defer g.mu.Unlock()
+					g.retrying = false
+					g.dispatchLocked()
+				})
+			}
+			return
+		}
+		item := heap.Pop(&g.queue).(queuedTask)
+		g.launchLocked(item.cfg, item.f)
+	}
+}
@@ -0,0 +1,252 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestSubmitPriority(t *testing.T) {
+	g := errgroup.WithLimit(1).WithQueue(10).New(context.Background())
+
+	release := make(chan struct{})
+	if err := g.Submit(0, func(ctx context.Context) error {
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	for _, tc := range []struct {
+		priority int
+		name     string
+	}{
+		{1, "low-1"},
+		{5, "high-1"},
+		{1, "low-2"},
+		{5, "high-2"},
+		{3, "mid"},
+	} {
+		if err := g.Submit(tc.priority, record(tc.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"high-1", "high-2", "mid", "low-1", "low-2"}
+	if len(order) != len(want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got %v, want %v", order, want)
+		}
+	}
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	g := errgroup.WithLimit(1).WithQueue(1).New(context.Background())
+	release := make(chan struct{})
+	fn := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+	if err := g.Submit(0, fn); err != nil {
+		t.Fatal(err) // starts immediately
+	}
+	if err := g.Submit(0, fn); err != nil {
+		t.Fatal(err) // queued
+	}
+	if err := g.Submit(0, fn); err != errgroup.ErrQueueFull {
+		t.Fatalf("got: %v, want: %v", err, errgroup.ErrQueueFull)
+	}
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmitWithoutQueue(t *testing.T) {
+	// Without WithQueue, Submit only succeeds if the task can start immediately.
+	g := errgroup.WithLimit(1).New(context.Background())
+	release := make(chan struct{})
+	if err := g.Submit(0, func(ctx context.Context) error {
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Submit(0, func(ctx context.Context) error { return nil }); err != errgroup.ErrQueueFull {
+		t.Fatalf("got: %v, want: %v", err, errgroup.ErrQueueFull)
+	}
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmitCancelDiscardsQueue(t *testing.T) {
+	errDoom := errors.New("queue_test: doomed")
+	g := errgroup.WithLimit(1).WithQueue(10).New(context.Background())
+
+	release := make(chan struct{})
+	_ = g.Submit(0, func(ctx context.Context) error {
+		<-release
+		return errDoom
+	})
+	var counter atomic.Int32
+	for i := 0; i < 5; i++ {
+		_ = g.Submit(0, func(ctx context.Context) error {
+			counter.Add(1)
+			return nil
+		})
+	}
+	close(release)
+	if err := g.Wait(); err != errDoom {
+		t.Fatalf("got: %v, want: %v", err, errDoom)
+	}
+	if got := counter.Load(); got != 0 {
+		t.Errorf("got %d queued calls after failure, want 0", got)
+	}
+	if err := g.Submit(0, func(ctx context.Context) error { return nil }); err != context.Canceled {
+		t.Errorf("got: %v, want: %v", err, context.Canceled)
+	}
+}
+
+func TestSubmitLimitZeroCancel(t *testing.T) {
+	// Nothing can ever start, so only cancellation discards the queue.
+	ctx, cancel := context.WithCancel(context.Background())
+	g := errgroup.WithLimit(0).WithQueue(1).New(ctx)
+	if err := g.Submit(0, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := g.Wait(); err != nil {
+		t.Fatalf("got: %v, want: nil", err)
+	}
+}
+
+func TestSubmitRateLimit(t *testing.T) {
+	g := errgroup.WithRateLimit(100, 1).WithQueue(10).New(context.Background())
+	var counter atomic.Int32
+	for i := 0; i < 5; i++ {
+		if err := g.Submit(0, func(ctx context.Context) error {
+			counter.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counter.Load(); got != 5 {
+		t.Errorf("got %d calls, want 5", got)
+	}
+}
+
+func TestSubmitShutdown(t *testing.T) {
+	g := errgroup.WithLimit(1).WithQueue(10).New(context.Background())
+	release := make(chan struct{})
+	_ = g.Submit(0, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	var counter atomic.Int32
+	_ = g.Submit(0, func(ctx context.Context) error {
+		counter.Add(1)
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- g.Shutdown(context.Background()) }()
+	for g.Submit(0, func(ctx context.Context) error { return nil }) != errgroup.ErrShutdown {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := counter.Load(); got != 1 {
+		t.Errorf("got %d queued calls, want 1", got)
+	}
+}
+
+func TestSubmitSharedLimiter(t *testing.T) {
+	l := errgroup.NewLimiter(1)
+	g1 := errgroup.WithLimiter(l).New(context.Background())
+	g2 := errgroup.WithLimiter(l).WithQueue(10).New(context.Background())
+
+	release := make(chan struct{})
+	g1.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	var ran atomic.Bool
+	if err := g2.Submit(0, func(ctx context.Context) error {
+		ran.Store(true)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if st := g2.Status(); st.Queued != 1 {
+		t.Fatalf("got %+v, want 1 queued", st)
+	}
+
+	// Weight freed by the other group reaches the queue.
+	close(release)
+	if err := g1.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !ran.Load() {
+		t.Error("queued task did not run")
+	}
+}
+
+func TestSubmitLimiterResize(t *testing.T) {
+	l := errgroup.NewLimiter(0)
+	g := errgroup.WithLimiter(l).WithQueue(10).New(context.Background())
+
+	var ran atomic.Int32
+	for i := 0; i < 3; i++ {
+		if err := g.Submit(0, func(ctx context.Context) error {
+			ran.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := g.Status(); st.Queued != 3 {
+		t.Fatalf("got %+v, want 3 queued", st)
+	}
+
+	// Growing the limit from zero starts the queue.
+	l.Resize(1)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ran.Load(); got != 3 {
+		t.Errorf("got %d tasks run, want 3", got)
+	}
+}
@@ -0,0 +1,74 @@
+package errgroup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket limits the rate of events, allowing bursts of up to burst events.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64 // may be negative, when tokens are reserved by waiters
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// refill adds the tokens accrued since the last refill. Must hold mu.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// wait blocks until a token is available and takes it, returning true, or either ctx or drain is
+// done, returning false.
+func (b *tokenBucket) wait(ctx context.Context, drain <-chan struct{}) bool {
+	b.mu.Lock()
+	b.refill(time.Now())
+	// Reserve a token, even if that puts us in debt; later waiters queue up behind us.
+	b.tokens--
+	if b.tokens >= 0 {
+		b.mu.Unlock()
+		return true
+	}
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+	case <-drain:
+	}
+
+	// Give back the reservation.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+	return false
+}
+
+// tryTake takes a token without blocking, reporting whether it succeeded.
+func (b *tokenBucket) tryTake() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
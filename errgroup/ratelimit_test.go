@@ -0,0 +1,84 @@
+package errgroup_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	const (
+		rate  = 100
+		burst = 5
+		n     = 25
+	)
+	g := errgroup.WithRateLimit(rate, burst).New(context.Background())
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		g.Go(func(ctx context.Context) error { return nil })
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	// The burst starts immediately; the rest are spaced at the rate.
+	if elapsed, want := time.Since(start), (n-burst)*time.Second/rate; elapsed < want*9/10 {
+		t.Errorf("started %d tasks in %s, want at least %s", n, elapsed, want)
+	}
+}
+
+func TestWithRateLimitTryGo(t *testing.T) {
+	g := errgroup.WithRateLimit(0.001, 2).New(context.Background())
+	fn := func(ctx context.Context) error { return nil }
+	if !g.TryGo(fn) || !g.TryGo(fn) {
+		t.Fatal("TryGo should succeed within the burst")
+	}
+	if g.TryGo(fn) {
+		t.Fatal("TryGo should fail once the burst is exhausted")
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithRateLimitCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := errgroup.WithRateLimit(0.001, 1).New(ctx)
+	var counter atomic.Int32
+	fn := func(ctx context.Context) error {
+		counter.Add(1)
+		return nil
+	}
+	g.Go(fn) // uses the burst
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	g.Go(fn) // blocks until cancelled
+
+	if err := g.Wait(); err != context.Canceled {
+		t.Fatalf("got: %v, want: %v", err, context.Canceled)
+	}
+	if got := counter.Load(); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+}
+
+func TestWithRateLimitAndLimit(t *testing.T) {
+	const limit = 2
+	g := errgroup.WithLimit(limit).WithRateLimit(1000, 10).New(context.Background())
+	var active atomic.Int32
+	for i := 0; i < 50; i++ {
+		g.Go(func(ctx context.Context) error {
+			if n := active.Add(1); n > limit {
+				t.Errorf("saw %d active goroutines; want ≤ %d", n, limit)
+			}
+			time.Sleep(time.Microsecond)
+			active.Add(-1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,136 @@
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrRetriesExhausted is wrapped by the error returned from [Retry] when a [Policy]
+// allows no further attempts.
+var ErrRetriesExhausted = errors.New("errgroup: retries exhausted")
+
+// Policy configures retries with exponential backoff.
+//
+// The zero Policy retries every error immediately and forever.
+type Policy struct {
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier scales the delay after each retry. Values less than 1 are treated as 2.
+	Multiplier float64
+	// Jitter randomly reduces each delay by up to this fraction, in [0, 1].
+	Jitter float64
+	// MaxAttempts limits the total number of attempts, including the first. Zero means no limit.
+	MaxAttempts int
+	// MaxElapsed limits the total time spent retrying, measured from the first attempt. Zero means no limit.
+	MaxElapsed time.Duration
+	// Retryable reports whether an error is transient and should be retried.
+	// A nil Retryable retries all errors.
+	Retryable func(error) bool
+}
+
+// NewBackoff returns a new Backoff tracking attempts made under this policy.
+func (p Policy) NewBackoff() *Backoff {
+	return &Backoff{policy: p, start: time.Now()}
+}
+
+func (p Policy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Backoff computes successive delays for a [Policy]. A Backoff is stateful and should not be
+// shared across goroutines.
+type Backoff struct {
+	policy  Policy
+	start   time.Time
+	retries int
+	delay   time.Duration
+}
+
+// Next returns the delay before the next attempt. The return value ok is false if the
+// policy's MaxAttempts or MaxElapsed would be exceeded.
+func (b *Backoff) Next() (delay time.Duration, ok bool) {
+	p := b.policy
+	if p.MaxAttempts > 0 && b.retries+1 >= p.MaxAttempts {
+		return 0, false
+	}
+
+	if b.retries == 0 {
+		b.delay = p.InitialBackoff
+	} else {
+		multiplier := p.Multiplier
+		if multiplier < 1 {
+			multiplier = 2
+		}
+		next := float64(b.delay) * multiplier
+		if next > math.MaxInt64 {
+			next = math.MaxInt64
+		}
+		b.delay = time.Duration(next)
+	}
+	if p.MaxBackoff > 0 && b.delay > p.MaxBackoff {
+		b.delay = p.MaxBackoff
+	}
+	b.retries++
+
+	delay = b.delay
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	if p.MaxElapsed > 0 && time.Since(b.start)+delay > p.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+// Reset restarts the backoff from the policy's InitialBackoff, e.g. after an attempt made progress.
+// Reset also restarts the MaxAttempts and MaxElapsed budgets.
+func (b *Backoff) Reset() {
+	b.start = time.Now()
+	b.retries = 0
+	b.delay = 0
+}
+
+// Wait sleeps for the next delay. It returns ctx.Err() if ctx is done first, or
+// ErrRetriesExhausted if the policy allows no further attempts.
+func (b *Backoff) Wait(ctx context.Context) error {
+	delay, ok := b.Next()
+	if !ok {
+		return ErrRetriesExhausted
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retry calls fn until it succeeds, returns an error that the policy does not consider retryable,
+// the policy allows no further attempts, or ctx is done. Panics are not caught.
+//
+// When giving up due to the policy, the returned error wraps both ErrRetriesExhausted and the
+// last error returned by fn. Otherwise, the last error returned by fn is returned as-is.
+func Retry(ctx context.Context, p Policy, fn func(context.Context) error) error {
+	b := p.NewBackoff()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || ctx.Err() != nil || !p.retryable(err) {
+			return err
+		}
+		switch waitErr := b.Wait(ctx); waitErr {
+		case nil:
+		case ErrRetriesExhausted:
+			return fmt.Errorf("%w after %d attempts: %w", ErrRetriesExhausted, attempt, err)
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestBackoff(t *testing.T) {
+	p := errgroup.Policy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		MaxAttempts:    6,
+	}
+	b := p.NewBackoff()
+	for i, want := range []time.Duration{1, 2, 4, 5, 5} {
+		got, ok := b.Next()
+		if !ok {
+			t.Fatalf("retry %d: expected ok", i)
+		}
+		if got != want*time.Second {
+			t.Errorf("retry %d: got: %s, want: %s", i, got, want*time.Second)
+		}
+	}
+	if _, ok := b.Next(); ok {
+		t.Error("expected MaxAttempts to be exhausted")
+	}
+
+	b.Reset()
+	if got, ok := b.Next(); !ok || got != time.Second {
+		t.Errorf("after Reset got: %s, %t, want: 1s, true", got, ok)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := errgroup.Policy{InitialBackoff: time.Second, Jitter: 0.5}.NewBackoff()
+	for i := 0; i < 100; i++ {
+		b.Reset()
+		got, _ := b.Next()
+		if got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("got: %s, want in [500ms, 1s]", got)
+		}
+	}
+}
+
+func TestBackoffMaxElapsed(t *testing.T) {
+	b := errgroup.Policy{InitialBackoff: time.Second, MaxElapsed: 1500 * time.Millisecond}.NewBackoff()
+	if _, ok := b.Next(); !ok {
+		t.Fatal("expected ok")
+	}
+	if _, ok := b.Next(); ok {
+		t.Fatal("expected MaxElapsed to be exhausted")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("retry_test: transient")
+	errFatal := errors.New("retry_test: fatal")
+	p := errgroup.Policy{
+		InitialBackoff: time.Millisecond,
+		MaxAttempts:    3,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errTransient)
+		},
+	}
+
+	t.Run("succeeds", func(t *testing.T) {
+		attempts := 0
+		err := errgroup.Retry(context.Background(), p, func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errTransient
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempts != 3 {
+			t.Errorf("got %d attempts, want 3", attempts)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		attempts := 0
+		err := errgroup.Retry(context.Background(), p, func(ctx context.Context) error {
+			attempts++
+			return errTransient
+		})
+		if !errors.Is(err, errgroup.ErrRetriesExhausted) || !errors.Is(err, errTransient) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("got %d attempts, want 3", attempts)
+		}
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		attempts := 0
+		err := errgroup.Retry(context.Background(), p, func(ctx context.Context) error {
+			attempts++
+			return errFatal
+		})
+		if err != errFatal {
+			t.Fatalf("got: %v, want: %v", err, errFatal)
+		}
+		if attempts != 1 {
+			t.Errorf("got %d attempts, want 1", attempts)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := errgroup.Retry(ctx, errgroup.Policy{InitialBackoff: time.Hour}, func(ctx context.Context) error {
+			cancel()
+			return errTransient
+		})
+		if err != errTransient {
+			t.Fatalf("got: %v, want: %v", err, errTransient)
+		}
+	})
+}
+
+func TestGoRetry(t *testing.T) {
+	errTransient := errors.New("retry_test: transient")
+	p := errgroup.Policy{InitialBackoff: time.Millisecond, MaxAttempts: 5}
+
+	g := errgroup.New(context.Background())
+	attempts := 0
+	g.GoRetry(p, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+
+	g = errgroup.New(context.Background())
+	g.GoRetry(p, func(ctx context.Context) error {
+		return errTransient
+	})
+	if err := g.Wait(); !errors.Is(err, errgroup.ErrRetriesExhausted) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Panics are not retried.
+	g = errgroup.New(context.Background())
+	attempts = 0
+	g.GoRetry(p, func(ctx context.Context) error {
+		attempts++
+		panic("test panic")
+	})
+	var pe *errgroup.PanicError
+	if err := g.Wait(); !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
@@ -0,0 +1,44 @@
+package errgroup
+
+import "context"
+
+// Recover converts an in-flight panic into a *PanicError stored in *errp.
+// It must be called directly via defer, typically with a named error result:
+//
+//	func work() (err error) {
+//		defer errgroup.Recover(&err)
+//		...
+//	}
+//
+// If there is no panic, *errp is left untouched.
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		*errp = NewPanicErrorCallers(r, 2)
+	}
+}
+
+// Safe calls fn inline, returning its error. Any panic thrown from fn is caught
+// and returned as a *PanicError.
+func Safe(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// SafeGo calls fn in a new goroutine, passing ctx. Any panic thrown from fn is caught
+// and converted into a *PanicError.
+//
+// If fn returns a non-nil error or panics, onErr is called with the error from the same
+// goroutine. A nil onErr discards the error.
+//
+// Unlike [ContextGroup.Go], nothing waits for the goroutine to finish; use SafeGo for
+// fire-and-forget work whose lifetime is otherwise bounded, e.g. by ctx.
+func SafeGo(ctx context.Context, fn func(context.Context) error, onErr func(error)) {
+	go func() {
+		err := Safe(func() error {
+			return fn(ctx)
+		})
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+	}()
+}
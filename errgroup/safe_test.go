@@ -0,0 +1,109 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestSafe(t *testing.T) {
+	errDoom := errors.New("safe_test: doomed")
+
+	if err := errgroup.Safe(func() error { return nil }); err != nil {
+		t.Errorf("got: %v, want: nil", err)
+	}
+	if err := errgroup.Safe(func() error { return errDoom }); err != errDoom {
+		t.Errorf("got: %v, want: %v", err, errDoom)
+	}
+
+	err := errgroup.Safe(func() error { panic(errDoom) })
+	var pe *errgroup.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+	if !errors.Is(err, errDoom) {
+		t.Errorf("expected panic error to wrap %v", errDoom)
+	}
+	// The first frame is the explicit call to panic, followed by our func.
+	frames := pe.Frames()
+	if len(frames) < 2 {
+		t.Fatalf("expected at least 2 frames, got %d", len(frames))
+	}
+	if frames[0].Function != "runtime.gopanic" {
+		t.Errorf("expected runtime.gopanic, got: %s", frames[0].Function)
+	}
+	if !strings.HasPrefix(frames[1].Function, "github.com/fullstorydev/go/errgroup_test.TestSafe.") {
+		t.Errorf("expected errgroup_test.TestSafe, got: %s", frames[1].Function)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	fn := func() (err error) {
+		defer errgroup.Recover(&err)
+		panic("test panic")
+	}
+	err := fn()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.HasPrefix(err.Error(), "panic: test panic") {
+		t.Fatalf("Error message mismatch: %v", err)
+	}
+
+	// No panic leaves the error untouched.
+	errDoom := errors.New("safe_test: doomed")
+	fn = func() (err error) {
+		defer errgroup.Recover(&err)
+		return errDoom
+	}
+	if err := fn(); err != errDoom {
+		t.Errorf("got: %v, want: %v", err, errDoom)
+	}
+}
+
+func TestSafeGo(t *testing.T) {
+	errs := make(chan error, 1)
+	errgroup.SafeGo(context.Background(), func(ctx context.Context) error {
+		panic("test panic")
+	}, func(err error) {
+		errs <- err
+	})
+	var pe *errgroup.PanicError
+	if err := <-errs; !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+
+	// Successful funcs do not call onErr.
+	done := make(chan struct{})
+	errgroup.SafeGo(context.Background(), func(ctx context.Context) error {
+		defer close(done)
+		return nil
+	}, func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	<-done
+
+	// A nil onErr discards the error.
+	done = make(chan struct{})
+	errgroup.SafeGo(context.Background(), func(ctx context.Context) error {
+		defer close(done)
+		panic("test panic")
+	}, nil)
+	<-done
+}
+
+func TestSafeGoContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	got := make(chan any, 1)
+	errgroup.SafeGo(ctx, func(ctx context.Context) error {
+		got <- ctx.Value(key{})
+		return nil
+	}, nil)
+	if v := <-got; v != "value" {
+		t.Errorf("got: %v, want: %v", v, "value")
+	}
+}
@@ -0,0 +1,75 @@
+package errgroup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type drainKey struct{}
+
+// Draining returns a channel which is closed when the [ContextGroup] owning ctx begins a graceful
+// [ContextGroup.Shutdown]. Tasks should finish their current work and return promptly once it is closed.
+//
+// If ctx does not belong to a ContextGroup, the returned channel is nil, and blocks forever.
+func Draining(ctx context.Context) <-chan struct{} {
+	drain, _ := ctx.Value(drainKey{}).(chan struct{})
+	return drain
+}
+
+// DrainTimeoutError is returned by [ContextGroup.Shutdown] when tasks are still running at the
+// drain deadline, and the group context is cancelled.
+type DrainTimeoutError struct {
+	// Running lists the tasks which were still running at the deadline.
+	Running []Task
+	// Err is the error from the shutdown context, usually [context.DeadlineExceeded].
+	Err error
+}
+
+var _ error = (*DrainTimeoutError)(nil)
+
+func (e *DrainTimeoutError) Error() string {
+	names := make([]string, len(e.Running))
+	for i, t := range e.Running {
+		names[i] = t.String()
+	}
+	return fmt.Sprintf("errgroup: shutdown: %v with %d tasks still running: %s", e.Err, len(e.Running), strings.Join(names, ", "))
+}
+
+// Unwrap returns the error from the shutdown context.
+func (e *DrainTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Shutdown gracefully stops the group.
+func (g *ctxGroup) Shutdown(ctx context.Context) error {
+	g.beginDrain()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.wg.Wait()
+	}()
+
+	select {
+	case <-done:
+		return g.Wait()
+	case <-ctx.Done():
+	}
+
+	// Hard cancel, reporting whichever tasks are still running.
+	g.mu.Lock()
+	running := make([]Task, 0, len(g.active))
+	for id, f := range g.active {
+		running = append(running, newTask(id, f))
+	}
+	g.mu.Unlock()
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].ID < running[j].ID
+	})
+
+	err := &DrainTimeoutError{Running: running, Err: ctx.Err()}
+	g.error(err)
+	return err
+}
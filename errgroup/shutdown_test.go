@@ -0,0 +1,121 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func drainingTask(ctx context.Context) error {
+	select {
+	case <-errgroup.Draining(ctx):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func stubbornTask(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestShutdown(t *testing.T) {
+	g := errgroup.New(context.Background())
+	g.Go(drainingTask)
+	g.Go(drainingTask)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownError(t *testing.T) {
+	errDoom := errors.New("shutdown_test: doomed")
+	g := errgroup.New(context.Background())
+	g.Go(func(ctx context.Context) error {
+		<-errgroup.Draining(ctx)
+		return errDoom
+	})
+	if err := g.Shutdown(context.Background()); err != errDoom {
+		t.Fatalf("got: %v, want: %v", err, errDoom)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	g := errgroup.New(context.Background())
+	g.Go(drainingTask)
+	g.Go(stubbornTask)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := g.Shutdown(ctx)
+	var de *errgroup.DrainTimeoutError
+	if !errors.As(err, &de) {
+		t.Fatalf("expected drain timeout error, got: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected drain timeout error to wrap context.DeadlineExceeded")
+	}
+	if len(de.Running) != 1 {
+		t.Fatalf("got %d running tasks, want 1: %v", len(de.Running), de.Running)
+	}
+	if got := de.Running[0]; got.ID != 2 || got.Name != "github.com/fullstorydev/go/errgroup_test.stubbornTask" {
+		t.Errorf("unexpected running task: %v", got)
+	}
+	if !strings.Contains(err.Error(), "stubbornTask") {
+		t.Errorf("unexpected message: %s", err)
+	}
+
+	// The hard cancel lets the stubborn task exit.
+	if err := g.Wait(); err != de {
+		t.Fatalf("got: %v, want: %v", err, de)
+	}
+}
+
+func TestShutdownRejectsNewTasks(t *testing.T) {
+	var counter atomic.Int32
+	fn := func(ctx context.Context) error {
+		counter.Add(1)
+		return nil
+	}
+
+	g := errgroup.WithLimit(1).New(context.Background())
+	g.Go(drainingTask)
+
+	// Blocked on the limit until Shutdown begins.
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		g.Go(fn)
+	}()
+
+	if err := g.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-blocked
+
+	g.Go(fn)
+	if !g.TryGo(fn) {
+		t.Error("TryGo should return true after Shutdown")
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := counter.Load(); got != 0 {
+		t.Errorf("got %d calls after Shutdown, want 0", got)
+	}
+}
+
+func TestDrainingOutsideGroup(t *testing.T) {
+	if ch := errgroup.Draining(context.Background()); ch != nil {
+		t.Errorf("expected nil channel, got: %v", ch)
+	}
+}
@@ -0,0 +1,36 @@
+package errgroup
+
+// Status is a snapshot of the progress of a [ContextGroup], see [ContextGroup.Status].
+type Status struct {
+	// Active is the number of tasks currently running.
+	Active int
+	// Queued is the number of tasks waiting in the group's queue (see [ContextGroup.Submit]).
+	Queued int
+	// Completed is the number of tasks which have returned nil.
+	Completed int
+	// Failed is the number of tasks which have returned an error or panicked, including non-fatal errors.
+	Failed int
+	// NonFatal is the number of non-fatal errors recorded so far (see [NonFatal]).
+	NonFatal int
+	// Err is the first fatal error recorded by the group so far, which cancelled the group.
+	Err error
+}
+
+// Status returns a snapshot of the group's progress.
+func (g *ctxGroup) Status() Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return Status{
+		Active:    len(g.active),
+		Queued:    len(g.queue),
+		Completed: g.completed,
+		Failed:    g.failed,
+		NonFatal:  len(g.errs),
+		Err:       g.err,
+	}
+}
+
+// Done returns a channel which is closed when the group records its first fatal error.
+func (g *ctxGroup) Done() <-chan struct{} {
+	return g.errDone
+}
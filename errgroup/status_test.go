@@ -0,0 +1,82 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func TestStatus(t *testing.T) {
+	errDoom := errors.New("status_test: doomed")
+	g := errgroup.WithLimit(1).WithQueue(1).New(context.Background())
+	if got := g.Status(); got != (errgroup.Status{}) {
+		t.Errorf("got: %+v, want zero status", got)
+	}
+
+	g.Go(func(ctx context.Context) error { return nil })
+	g.Go(func(ctx context.Context) error { return nil })
+
+	release := make(chan struct{})
+	g.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if err := g.Submit(0, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	want := errgroup.Status{Active: 1, Queued: 1, Completed: 2}
+	if got := g.Status(); got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+	select {
+	case <-g.Done():
+		t.Fatal("Done closed before any error")
+	default:
+	}
+	close(release)
+	for g.Status().Completed < 4 {
+		time.Sleep(time.Millisecond) // let the queued task run
+	}
+
+	g.Go(func(ctx context.Context) error { return errDoom })
+	<-g.Done()
+	if err := g.Wait(); err != errDoom {
+		t.Fatalf("got: %v, want: %v", err, errDoom)
+	}
+	want = errgroup.Status{Completed: 4, Failed: 1, Err: errDoom}
+	if got := g.Status(); got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
+func TestStatusPanic(t *testing.T) {
+	g := errgroup.New(context.Background())
+	g.Go(func(ctx context.Context) error { panic("status_test: panic") })
+	<-g.Done()
+	_ = g.Wait()
+
+	st := g.Status()
+	if st.Failed != 1 || st.Completed != 0 {
+		t.Errorf("got: %+v, want 1 failed task", st)
+	}
+	var pe *errgroup.PanicError
+	if !errors.As(st.Err, &pe) {
+		t.Errorf("expected panic error, got: %v", st.Err)
+	}
+}
+
+func TestDoneOnSuccess(t *testing.T) {
+	g := errgroup.New(context.Background())
+	g.Go(func(ctx context.Context) error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-g.Done():
+		t.Fatal("Done closed without an error")
+	default:
+	}
+}
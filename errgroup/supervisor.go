@@ -0,0 +1,284 @@
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRestartLimit is wrapped by the error returned from [Supervisor.Run] when a child fails
+// more often than the supervisor's restart intensity allows.
+var ErrRestartLimit = errors.New("errgroup: supervisor restart limit exceeded")
+
+// Strategy determines which children a [Supervisor] restarts when one of them fails.
+type Strategy int
+
+const (
+	// OneForOne restarts only the failed child.
+	OneForOne Strategy = iota
+	// OneForAll stops and restarts all children when any child fails.
+	OneForAll
+	// RestForOne stops and restarts the failed child, plus all children added after it.
+	RestForOne
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	case RestForOne:
+		return "rest-for-one"
+	default:
+		return fmt.Sprintf("Strategy(%d)", int(s))
+	}
+}
+
+// Supervisor runs long-lived child functions, restarting them when they fail rather than
+// cancelling everything on the first error, as [ContextGroup] does.
+//
+// A child fails when it returns a non-nil error or panics; panics are caught and converted into
+// a *PanicError. A child which returns nil is considered finished, and is not restarted.
+//
+// Configure the exported fields and call Add for each child before calling Run. A Supervisor
+// cannot be reused after Run returns.
+type Supervisor struct {
+	// Strategy determines which children are restarted when a child fails.
+	Strategy Strategy
+	// MaxRestarts is the number of restarts allowed within any Period. Once exceeded, Run stops
+	// all children and returns an error wrapping ErrRestartLimit. Zero means a single failure is
+	// terminal; a negative value means no limit.
+	MaxRestarts int
+	// Period is the sliding window for MaxRestarts. Zero means the supervisor's entire lifetime.
+	Period time.Duration
+	// Backoff determines the delay before each restart. Consecutive restarts within Period back off
+	// further. If the Backoff exhausts its MaxAttempts or MaxElapsed, the restart limit is exceeded.
+	Backoff Policy
+	// OnError, if non-nil, is called with each child failure, e.g. for logging.
+	OnError func(name string, err error)
+
+	children []supervisedChild
+	started  bool
+}
+
+type supervisedChild struct {
+	name string
+	fn   func(context.Context) error
+
+	gen      int // incremented on each start
+	cancel   context.CancelFunc
+	running  bool
+	finished bool
+}
+
+type childExit struct {
+	index int
+	gen   int
+	err   error
+}
+
+// Add registers a named child function, which will be called in a new goroutine by Run.
+// Children are started in the order they are added. Add must not be called after Run.
+func (s *Supervisor) Add(name string, fn func(context.Context) error) {
+	if s.started {
+		panic("errgroup: Supervisor.Add called after Run")
+	}
+	s.children = append(s.children, supervisedChild{name: name, fn: fn})
+}
+
+// Run starts all children and supervises them until ctx is done, all children have finished,
+// or the restart limit is exceeded. Run stops all children and waits for them to exit before
+// returning.
+//
+// Run returns nil unless the restart limit is exceeded.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if s.started {
+		panic("errgroup: Supervisor.Run called twice")
+	}
+	s.started = true
+
+	r := &supervisorRun{
+		Supervisor: s,
+		ctx:        ctx,
+		exits:      make(chan childExit, len(s.children)),
+		backoff:    s.Backoff.NewBackoff(),
+	}
+	defer r.stopAll()
+	for i := range s.children {
+		r.start(i)
+	}
+	return r.loop()
+}
+
+// supervisorRun holds the state of a single call to Supervisor.Run.
+type supervisorRun struct {
+	*Supervisor
+	ctx      context.Context
+	exits    chan childExit
+	pending  []childExit // reaped exits which have not been handled yet
+	running  int
+	restarts []time.Time
+	backoff  *Backoff
+}
+
+func (r *supervisorRun) loop() error {
+	for {
+		var ex childExit
+		if len(r.pending) > 0 {
+			ex, r.pending = r.pending[0], r.pending[1:]
+		} else if r.running == 0 {
+			return nil // all children finished
+		} else {
+			select {
+			case <-r.ctx.Done():
+				return nil
+			case ex = <-r.exits:
+				r.reap(ex)
+			}
+		}
+
+		c := &r.children[ex.index]
+		if ex.gen != c.gen || r.ctx.Err() != nil {
+			continue // stale, or shutting down
+		}
+		if ex.err == nil {
+			c.finished = true
+			continue
+		}
+		if r.OnError != nil {
+			r.OnError(c.name, ex.err)
+		}
+		if err := r.restart(ex.index, ex.err); err != nil {
+			return err
+		}
+	}
+}
+
+// restart applies the restart strategy after child i failed with err.
+func (r *supervisorRun) restart(i int, err error) error {
+	name := r.children[i].name
+	if !r.allowRestart() {
+		return fmt.Errorf("%w: child %q: %w", ErrRestartLimit, name, err)
+	}
+
+	var set []int
+	switch r.Strategy {
+	case OneForAll:
+		for j := range r.children {
+			set = append(set, j)
+		}
+	case RestForOne:
+		for j := i; j < len(r.children); j++ {
+			set = append(set, j)
+		}
+	default:
+		set = []int{i}
+	}
+	r.stop(set)
+
+	switch waitErr := r.backoff.Wait(r.ctx); waitErr {
+	case nil:
+	case ErrRetriesExhausted:
+		return fmt.Errorf("%w: child %q: %w", ErrRestartLimit, name, err)
+	default:
+		return nil // ctx is done
+	}
+
+	for _, j := range set {
+		if j == i || !r.children[j].finished {
+			r.start(j)
+		}
+	}
+	return nil
+}
+
+// allowRestart records a restart and reports whether it is within the restart intensity.
+func (r *supervisorRun) allowRestart() bool {
+	now := time.Now()
+	if r.Period > 0 {
+		recent := r.restarts[:0]
+		for _, t := range r.restarts {
+			if now.Sub(t) < r.Period {
+				recent = append(recent, t)
+			}
+		}
+		r.restarts = recent
+	}
+	if len(r.restarts) == 0 {
+		r.backoff.Reset()
+	}
+	if r.MaxRestarts < 0 {
+		if r.Period <= 0 && len(r.restarts) > 0 {
+			return true // unlimited; avoid growing without bound
+		}
+	} else if len(r.restarts) >= r.MaxRestarts {
+		return false
+	}
+	r.restarts = append(r.restarts, now)
+	return true
+}
+
+func (r *supervisorRun) start(i int) {
+	c := &r.children[i]
+	ctx, cancel := context.WithCancel(r.ctx)
+	c.gen++
+	c.cancel = cancel
+	c.running = true
+	c.finished = false
+	r.running++
+
+	ex := childExit{index: i, gen: c.gen}
+	fn := c.fn
+	go func() {
+		ex.err = Safe(func() error {
+			return fn(ctx)
+		})
+		r.exits <- ex
+	}()
+}
+
+// reap records that a child's goroutine has exited.
+func (r *supervisorRun) reap(ex childExit) {
+	c := &r.children[ex.index]
+	c.running = false
+	c.cancel()
+	r.running--
+}
+
+// stop cancels the given children and waits for them to exit. Exits from other children
+// are reaped and queued for later handling.
+func (r *supervisorRun) stop(set []int) {
+	stopping := map[int]bool{}
+	for _, j := range set {
+		if c := &r.children[j]; c.running {
+			c.cancel()
+			stopping[j] = true
+		}
+	}
+	for len(stopping) > 0 {
+		ex := <-r.exits
+		r.reap(ex)
+		if stopping[ex.index] {
+			delete(stopping, ex.index)
+		} else {
+			if ex.err == nil {
+				r.children[ex.index].finished = true // do not restart below
+			}
+			r.pending = append(r.pending, ex)
+		}
+	}
+}
+
+// stopAll cancels all children and waits for them to exit.
+func (r *supervisorRun) stopAll() {
+	for i := range r.children {
+		if c := &r.children[i]; c.running {
+			c.cancel()
+		}
+	}
+	for r.running > 0 {
+		r.reap(<-r.exits)
+	}
+}
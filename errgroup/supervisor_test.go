@@ -0,0 +1,185 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+// failN returns a child which fails n times, then blocks until cancelled.
+func failN(n int32, starts *atomic.Int32) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if starts.Add(1) <= n {
+			return errors.New("supervisor_test: failed")
+		}
+		<-ctx.Done()
+		return nil
+	}
+}
+
+// blocker returns a child which blocks until cancelled, counting its starts.
+func blocker(starts *atomic.Int32) func(context.Context) error {
+	return func(ctx context.Context) error {
+		starts.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func runUntil(t *testing.T, s *errgroup.Supervisor, cond func() bool) error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() { errs <- s.Run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		select {
+		case err := <-errs:
+			return err
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	return <-errs
+}
+
+func TestSupervisorOneForOne(t *testing.T) {
+	var a, b atomic.Int32
+	s := &errgroup.Supervisor{Strategy: errgroup.OneForOne, MaxRestarts: -1}
+	s.Add("a", failN(3, &a))
+	s.Add("b", blocker(&b))
+	err := runUntil(t, s, func() bool { return a.Load() == 4 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Load(); got != 1 {
+		t.Errorf("b started %d times, want 1", got)
+	}
+}
+
+func TestSupervisorOneForAll(t *testing.T) {
+	var a, b atomic.Int32
+	s := &errgroup.Supervisor{Strategy: errgroup.OneForAll, MaxRestarts: -1}
+	s.Add("a", blocker(&a))
+	s.Add("b", failN(2, &b))
+	err := runUntil(t, s, func() bool { return b.Load() == 3 && a.Load() == 3 })
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSupervisorRestForOne(t *testing.T) {
+	var a, b, c atomic.Int32
+	s := &errgroup.Supervisor{Strategy: errgroup.RestForOne, MaxRestarts: -1}
+	s.Add("a", blocker(&a))
+	s.Add("b", failN(2, &b))
+	s.Add("c", blocker(&c))
+	err := runUntil(t, s, func() bool { return b.Load() == 3 && c.Load() == 3 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Load(); got != 1 {
+		t.Errorf("a started %d times, want 1", got)
+	}
+}
+
+func TestSupervisorRestartLimit(t *testing.T) {
+	var starts atomic.Int32
+	var mu sync.Mutex
+	var failures []string
+	s := &errgroup.Supervisor{
+		MaxRestarts: 2,
+		Period:      time.Minute,
+		OnError: func(name string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, name)
+		},
+	}
+	s.Add("flaky", func(ctx context.Context) error {
+		starts.Add(1)
+		panic("test panic")
+	})
+	err := s.Run(context.Background())
+	if !errors.Is(err, errgroup.ErrRestartLimit) {
+		t.Fatalf("expected restart limit error, got: %v", err)
+	}
+	var pe *errgroup.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected panic error, got: %v", err)
+	}
+	if got := starts.Load(); got != 3 {
+		t.Errorf("started %d times, want 3", got)
+	}
+	if len(failures) != 3 {
+		t.Errorf("got %d failures, want 3", len(failures))
+	}
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	var starts atomic.Int32
+	s := &errgroup.Supervisor{
+		MaxRestarts: -1,
+		Backoff:     errgroup.Policy{InitialBackoff: time.Millisecond, MaxAttempts: 3},
+	}
+	s.Add("flaky", failN(100, &starts))
+	err := s.Run(context.Background())
+	if !errors.Is(err, errgroup.ErrRestartLimit) {
+		t.Fatalf("expected restart limit error, got: %v", err)
+	}
+	if got := starts.Load(); got != 3 {
+		t.Errorf("started %d times, want 3", got)
+	}
+}
+
+func TestSupervisorFinished(t *testing.T) {
+	var a, b atomic.Int32
+	s := &errgroup.Supervisor{Strategy: errgroup.OneForAll, MaxRestarts: -1}
+	s.Add("a", func(ctx context.Context) error {
+		a.Add(1)
+		return nil
+	})
+	s.Add("b", func(ctx context.Context) error {
+		if b.Add(1) < 3 {
+			return errors.New("supervisor_test: failed")
+		}
+		return nil
+	})
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Load(); got != 3 {
+		t.Errorf("b started %d times, want 3", got)
+	}
+	// a may be restarted along with b until it is seen to have finished.
+	if got := a.Load(); got < 1 || got > 3 {
+		t.Errorf("a started %d times, want 1-3", got)
+	}
+}
+
+func TestSupervisorCancel(t *testing.T) {
+	var a atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &errgroup.Supervisor{}
+	s.Add("a", blocker(&a))
+	go func() {
+		for a.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	// Children exiting due to cancellation are not failures.
+	if err := s.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
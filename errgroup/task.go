@@ -0,0 +1,35 @@
+package errgroup
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// Task identifies a function started in a [ContextGroup].
+type Task struct {
+	// ID is the sequence number of the task within its group, starting at 1.
+	ID int64
+	// Name is the name of the task function, as reported by the runtime.
+	Name string
+}
+
+func newTask(id int64, f any) Task {
+	return Task{ID: id, Name: funcName(f)}
+}
+
+func (t Task) String() string {
+	return fmt.Sprintf("task %d (%s)", t.ID, t.Name)
+}
+
+// funcName returns the runtime name of the given func value.
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "<nil>"
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "<unknown>"
+}
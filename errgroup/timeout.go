@@ -0,0 +1,29 @@
+package errgroup
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TaskTimeoutError is returned by [ContextGroup.Wait] when a task fails after its own
+// timeout has expired. It wraps both [context.DeadlineExceeded] and the error returned by the task.
+type TaskTimeoutError struct {
+	// Task identifies the task which timed out.
+	Task Task
+	// Timeout is the timeout the task was started with.
+	Timeout time.Duration
+	// Err is the error returned by the task.
+	Err error
+}
+
+var _ error = (*TaskTimeoutError)(nil)
+
+func (e *TaskTimeoutError) Error() string {
+	return fmt.Sprintf("errgroup: %s exceeded timeout of %s: %v", e.Task, e.Timeout, e.Err)
+}
+
+// Unwrap returns [context.DeadlineExceeded] and the error returned by the task.
+func (e *TaskTimeoutError) Unwrap() []error {
+	return []error{context.DeadlineExceeded, e.Err}
+}
@@ -0,0 +1,120 @@
+package errgroup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+)
+
+func slowTask(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestGoWithTimeout(t *testing.T) {
+	g := errgroup.New(context.Background())
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done() // wait until the sibling's timeout cancels the group
+		return nil
+	})
+	g.GoWithTimeout(10*time.Millisecond, slowTask)
+
+	err := g.Wait()
+	var te *errgroup.TaskTimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected timeout error to wrap context.DeadlineExceeded")
+	}
+	if te.Task.ID != 2 {
+		t.Errorf("got task id %d, want 2", te.Task.ID)
+	}
+	if te.Task.Name != "github.com/fullstorydev/go/errgroup_test.slowTask" {
+		t.Errorf("unexpected task name: %s", te.Task.Name)
+	}
+	if te.Timeout != 10*time.Millisecond {
+		t.Errorf("got timeout %s, want 10ms", te.Timeout)
+	}
+	if !strings.Contains(err.Error(), "task 2 (github.com/fullstorydev/go/errgroup_test.slowTask) exceeded timeout of 10ms") {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
+
+func TestGoWithTimeoutSuccess(t *testing.T) {
+	g := errgroup.New(context.Background())
+	g.GoWithTimeout(time.Minute, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("expected a deadline")
+		}
+		return nil
+	})
+	g.GoWithTimeout(0, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			return errors.New("expected no deadline")
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGoWithTimeoutIgnored(t *testing.T) {
+	// Tasks that return nil despite timing out do not fail the group.
+	g := errgroup.New(context.Background())
+	g.GoWithTimeout(time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGoWithTimeoutGroupCancelled(t *testing.T) {
+	// Group cancellation is not reported as a task timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	g := errgroup.New(ctx)
+	g.GoWithTimeout(time.Minute, slowTask)
+	cancel()
+	err := g.Wait()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	var te *errgroup.TaskTimeoutError
+	if errors.As(err, &te) {
+		t.Fatalf("unexpected timeout error: %v", err)
+	}
+}
+
+func TestWithTaskTimeout(t *testing.T) {
+	g := errgroup.WithTaskTimeout(10 * time.Millisecond).WithLimit(1).New(context.Background())
+	if !g.TryGo(slowTask) {
+		t.Fatal("TryGo should succeed")
+	}
+	err := g.Wait()
+	var te *errgroup.TaskTimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected timeout error, got: %v", err)
+	}
+	if te.Task.ID != 1 {
+		t.Errorf("got task id %d, want 1", te.Task.ID)
+	}
+
+	// An explicit timeout overrides the default.
+	g = errgroup.WithLimit(1).WithTaskTimeout(time.Millisecond).New(context.Background())
+	g.GoWithTimeout(time.Minute, func(ctx context.Context) error {
+		if deadline, _ := ctx.Deadline(); time.Until(deadline) < time.Second {
+			return errors.New("expected the explicit timeout")
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,136 @@
+package errgroup
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// weighted is a semaphore with FIFO admission: a waiter is never overtaken by a later one,
+// even if the later one would fit.
+type weighted struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List // of weightedWaiter
+
+	// watchers are signalled whenever capacity may have been freed, for callers which only
+	// use tryAcquire and so are never admitted as waiters.
+	watchers map[chan<- struct{}]struct{}
+}
+
+type weightedWaiter struct {
+	n     int64
+	ready chan struct{} // closed when the weight has been acquired on the waiter's behalf
+}
+
+func newWeighted(size int64) *weighted {
+	return &weighted{size: size}
+}
+
+// acquire blocks until n is acquired, returning true, or either ctx or drain is done, returning false.
+func (w *weighted) acquire(ctx context.Context, drain <-chan struct{}, n int64) bool {
+	w.mu.Lock()
+	if w.size-w.cur >= n && w.waiters.Len() == 0 {
+		w.cur += n
+		w.mu.Unlock()
+		return true
+	}
+	ready := make(chan struct{})
+	elem := w.waiters.PushBack(weightedWaiter{n: n, ready: ready})
+	w.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-ctx.Done():
+	case <-drain:
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-ready:
+		// Acquired after giving up; put the weight back.
+		w.cur -= n
+	default:
+		w.waiters.Remove(elem)
+	}
+	// Either way, waiters behind us may now fit.
+	w.notifyWaiters()
+	return false
+}
+
+// tryAcquire acquires n without blocking, reporting whether it succeeded.
+func (w *weighted) tryAcquire(n int64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size-w.cur >= n && w.waiters.Len() == 0 {
+		w.cur += n
+		return true
+	}
+	return false
+}
+
+// release returns n previously acquired.
+func (w *weighted) release(n int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cur -= n
+	if w.cur < 0 {
+		panic("errgroup: released more weight than held")
+	}
+	w.notifyWaiters()
+}
+
+// resize changes the size, admitting any waiters which now fit.
+func (w *weighted) resize(size int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.size = size
+	w.notifyWaiters()
+}
+
+// watch registers c to be signalled, without blocking, whenever capacity may have been freed.
+func (w *weighted) watch(c chan<- struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.watchers == nil {
+		w.watchers = map[chan<- struct{}]struct{}{}
+	}
+	w.watchers[c] = struct{}{}
+}
+
+// unwatch unregisters c.
+func (w *weighted) unwatch(c chan<- struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.watchers, c)
+}
+
+// notifyWaiters admits waiters in FIFO order for as long as they fit, then signals the watchers
+// if any capacity remains. Must hold mu.
+func (w *weighted) notifyWaiters() {
+	for {
+		front := w.waiters.Front()
+		if front == nil {
+			if w.cur < w.size {
+				for c := range w.watchers {
+					select {
+					case c <- struct{}{}:
+					default: // already signalled
+					}
+				}
+			}
+			return
+		}
+		waiter := front.Value.(weightedWaiter)
+		if w.size-w.cur < waiter.n {
+			// Do not let smaller waiters behind this one barge ahead, which could starve it.
+			return
+		}
+		w.cur += waiter.n
+		w.waiters.Remove(front)
+		close(waiter.ready)
+	}
+}
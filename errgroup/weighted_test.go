@@ -0,0 +1,147 @@
+package errgroup
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until the semaphore has n queued waiters.
+func waitForWaiters(t *testing.T, w *weighted, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w.mu.Lock()
+		got := w.waiters.Len()
+		w.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d waiters, have %d", n, got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGoWeightedLimit(t *testing.T) {
+	const capacity = 10
+
+	g := WithWeightedLimit(capacity).New(context.Background())
+	var active int64
+	for i := 0; i <= 1<<8; i++ {
+		weight := int64(i%capacity + 1)
+		g.GoWeighted(weight, func(ctx context.Context) error {
+			n := atomic.AddInt64(&active, weight)
+			if n > capacity {
+				return errors.New("exceeded weighted limit")
+			}
+			time.Sleep(time.Microsecond) // Give other goroutines a chance to increment active.
+			atomic.AddInt64(&active, -weight)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGoWeightedFIFO(t *testing.T) {
+	g := WithWeightedLimit(10).New(context.Background())
+	w := g.(*ctxGroup).weights
+
+	release := make(chan struct{})
+	g.GoWeighted(6, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	// The heavy task must wait for the first to finish.
+	var order []string
+	started := make(chan string, 2)
+	go g.GoWeighted(10, func(ctx context.Context) error {
+		started <- "heavy"
+		return nil
+	})
+	waitForWaiters(t, w, 1)
+
+	// The light task would fit, but must not barge ahead of the heavy one.
+	go g.GoWeighted(1, func(ctx context.Context) error {
+		started <- "light"
+		return nil
+	})
+	waitForWaiters(t, w, 2)
+	if g.TryGo(func(ctx context.Context) error { return nil }) {
+		t.Error("TryGo should not barge ahead of waiters")
+	}
+
+	close(release)
+	order = append(order, <-started, <-started)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if order[0] != "heavy" || order[1] != "light" {
+		t.Errorf("got order %v, want [heavy light]", order)
+	}
+}
+
+func TestGoWeightedCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := WithWeightedLimit(1).New(ctx)
+	w := g.(*ctxGroup).weights
+
+	g.GoWeighted(1, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	// Blocks until cancelled, like the sem select in Go.
+	var called atomic.Bool
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		g.GoWeighted(1, func(ctx context.Context) error {
+			called.Store(true)
+			return nil
+		})
+	}()
+	waitForWaiters(t, w, 1)
+	cancel()
+	<-returned
+
+	if err := g.Wait(); err != context.Canceled {
+		t.Fatalf("got: %v, want: %v", err, context.Canceled)
+	}
+	if called.Load() {
+		t.Error("cancelled task should not be called")
+	}
+	if w.cur != 0 || w.waiters.Len() != 0 {
+		t.Errorf("expected all weight released, have %d held and %d waiters", w.cur, w.waiters.Len())
+	}
+}
+
+func TestGoWeightedWithLimit(t *testing.T) {
+	// Without a weighted limit, weight is ignored.
+	g := WithLimit(1).New(context.Background())
+	g.GoWeighted(100, func(ctx context.Context) error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both limits apply.
+	g = WithLimit(1).WithWeightedLimit(10).New(context.Background())
+	release := make(chan struct{})
+	g.GoWeighted(1, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if g.TryGo(func(ctx context.Context) error { return nil }) {
+		t.Error("TryGo should fail under WithLimit(1)")
+	}
+	close(release)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,23 @@
+package chatclient
+
+import (
+	"context"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TokenCredentials returns per-RPC credentials presenting the given bearer token, for use with
+// grpc.WithPerRPCCredentials. They require a secure transport, so the token is never sent in the clear.
+func TokenCredentials(token string) credentials.PerRPCCredentials {
+	return tokenCredentials(token)
+}
+
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
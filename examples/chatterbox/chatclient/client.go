@@ -5,15 +5,17 @@ import (
 	"fmt"
 	"log"
 	"sync"
-	"time"
 
 	"github.com/fullstorydev/go/examples/chatterbox"
 )
 
-// RunClient is an example of a gRPC client for a two-way bidi stream.
-func RunClient(ctx context.Context, chatInput <-chan string, cl chatterbox.ChatterBoxClient) error {
+// RunClient is an example of a gRPC client for a two-way bidi stream, chatting in the given room.
+// If nick is empty, the server picks a nickname.
+func RunClient(ctx context.Context, room, nick string, chatInput <-chan *chatterbox.Send, cl chatterbox.ChatterBoxClient) error {
 	mc := &MembersClient{
 		cl:        cl,
+		room:      room,
+		nick:      nick,
 		chatInput: chatInput,
 	}
 
@@ -27,10 +29,14 @@ func RunClient(ctx context.Context, chatInput <-chan string, cl chatterbox.Chatt
 
 type MembersClient struct {
 	cl        chatterbox.ChatterBoxClient
-	chatInput <-chan string
-
-	mu      sync.RWMutex
-	members chatterbox.MembersModel
+	room      string
+	chatInput <-chan *chatterbox.Send
+
+	mu       sync.RWMutex
+	nick     string              // requested when (re)connecting
+	presence chatterbox.Presence // restored when reconnecting
+	lastSeq  int64               // the seq of the last event seen, to resume from when reconnecting
+	members  chatterbox.MembersModel
 }
 
 // Start this MembersClient. Fetches the initial state synchronously, then background monitors until ctx is cancelled.
@@ -52,11 +58,11 @@ func (mc *MembersClient) Start(ctx context.Context) error {
 	started = true
 
 	go func() {
-		// Monitor the first stream until it dies.
-		if err := mc.monitorStream(ctx, stream); err != nil {
-			log.Println(err)
-		}
-		if ctx.Err() != nil {
+		// Monitor the first stream until it dies, then wait if the server asked us to.
+		if _, err := waitForServer(ctx, mc.monitorStream(ctx, stream)); err != nil {
+			if ctx.Err() == nil {
+				log.Println(err) // the server will not have us back
+			}
 			return
 		}
 		// Run until ctx is cancelled.
@@ -67,10 +73,10 @@ func (mc *MembersClient) Start(ctx context.Context) error {
 	return nil
 }
 
-// Run runs this MembersClient in the foreground until ctx is cancelled.
+// Run runs this MembersClient in the foreground until ctx is cancelled, or until the server will not have it back,
+// such as when it is kicked, returning the error which ended its last stream.
 func (mc *MembersClient) Run(ctx context.Context) error {
-	const maxBackoff = 16 * time.Second
-	backoff := time.Second
+	backoff := reconnectPolicy.NewBackoff()
 
 	// Loop forever until killed or cancelled.
 	for {
@@ -85,23 +91,22 @@ func (mc *MembersClient) Run(ctx context.Context) error {
 
 			return true, mc.monitorStream(ctx, stream)
 		}()
+		waited, err := waitForServer(ctx, err)
 		if err != nil {
-			log.Println(err)
-		}
-
-		if ok {
-			backoff = time.Second
-		} else {
-			backoff *= 2
-			if backoff > maxBackoff {
-				backoff = maxBackoff
+			if ctx.Err() != nil {
+				return nil
 			}
+			return err
 		}
 
-		select {
-		case <-ctx.Done():
+		if ok || waited {
+			backoff.Reset()
+		}
+		if waited {
+			continue // the server told us how long to wait
+		}
+		if err := backoff.Wait(ctx); err != nil {
 			return nil
-		case <-time.After(backoff):
 		}
 	}
 }
@@ -112,17 +117,26 @@ func (mc *MembersClient) startStream(ctx context.Context) (chatterbox.ChatterBox
 		return nil, fmt.Errorf("cl.Chat: %w", err)
 	}
 
-	members, err := fetchInitialState(ctx, stream)
+	// The first message joins the room, resuming from the last event we saw, if any.
+	mc.mu.RLock()
+	nick, presence, lastSeq, members := mc.nick, mc.presence, mc.lastSeq, mc.members
+	mc.mu.RUnlock()
+	if err := stream.Send(&chatterbox.Send{Room: mc.room, Nick: nick, ResumeAfter: lastSeq, Presence: &presence}); err != nil {
+		return nil, fmt.Errorf("stream.Send: %w", err)
+	}
+
+	members, seq, err := fetchInitialState(ctx, stream, members)
 	if err != nil {
 		return nil, fmt.Errorf("fetchInitialState: %w", err)
 	}
 
 	// Successfully fetched initial state.
-	log.Printf("Members: %+v", members)
+	log.Printf("Members of %s: %+v", mc.room, members)
 	func() {
 		mc.mu.Lock()
 		defer mc.mu.Unlock()
 		mc.members = members
+		mc.lastSeq = seq
 	}()
 	return stream, nil
 }
@@ -148,12 +162,13 @@ func (mc *MembersClient) monitorStream(ctx context.Context, stream chatterbox.Ch
 				if !ok {
 					return
 				}
-				if err := stream.Send(&chatterbox.Send{
-					Text: msg,
-				}); err != nil {
+				if err := stream.Send(msg); err != nil {
 					log.Printf("Failed to send: %s", err)
 					return
 				}
+				if msg.To != "" && msg.Text != "" {
+					log.Printf("-> %s: %s", msg.To, msg.Text)
+				}
 			}
 		}
 	}()
@@ -165,25 +180,24 @@ func (mc *MembersClient) monitorStream(ctx context.Context, stream chatterbox.Ch
 			return filterClientError(err)
 		}
 
-		switch msg.What {
-		case chatterbox.What_CHAT:
-			log.Printf("%s: %s", msg.Who, msg.Text)
-		case chatterbox.What_JOIN:
-			func() {
-				mc.mu.Lock()
-				defer mc.mu.Unlock()
-				mc.members.Add(msg.Who)
-			}()
-			log.Printf("%s: joined", msg.Who)
-		case chatterbox.What_LEAVE:
-			func() {
-				mc.mu.Lock()
-				defer mc.mu.Unlock()
-				mc.members.Remove(msg.Who)
-			}()
-			log.Printf("%s: left", msg.Who)
-		default:
-			return fmt.Errorf("unexpected type: %s", msg.What)
+		if err := func() error {
+			mc.mu.Lock()
+			defer mc.mu.Unlock()
+			if msg.Seq != 0 {
+				mc.lastSeq = msg.Seq // direct messages are not part of the room's sequence
+			}
+			if msg.What == chatterbox.What_RENAME && msg.Who == mc.nick {
+				mc.nick = msg.Text // keep our new name when reconnecting
+			}
+			if msg.What == chatterbox.What_PRESENCE && msg.Who == mc.nick {
+				mc.presence = msg.Presence // likewise our presence
+			}
+			if err := applyEvent(mc.members, msg); err != nil {
+				return err
+			}
+			return checkShutdown(msg)
+		}(); err != nil {
+			return err
 		}
 	}
 }
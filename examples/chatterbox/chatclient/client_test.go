@@ -0,0 +1,795 @@
+package chatclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/errgroup"
+	"github.com/fullstorydev/go/examples/chatterbox"
+	"github.com/fullstorydev/go/examples/chatterbox/chatserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testTimeout = 5 * time.Second
+
+// streamKiller is a client interceptor which can kill the current stream, and hold back new
+// streams until released. It also records every event received.
+type streamKiller struct {
+	t      *testing.T
+	events chan *chatterbox.Event
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	gate   chan struct{}
+}
+
+func newStreamKiller(t *testing.T) *streamKiller {
+	return &streamKiller{t: t, events: make(chan *chatterbox.Event, 1000)}
+}
+
+func (k *streamKiller) intercept(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	k.mu.Lock()
+	gate := k.gate
+	k.mu.Unlock()
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	k.mu.Lock()
+	k.cancel = cancel
+	k.mu.Unlock()
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &recordingStream{ClientStream: cs, events: k.events}, nil
+}
+
+// kill kills the current stream, and holds back new streams until release is called.
+func (k *streamKiller) kill() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.gate = make(chan struct{})
+	k.cancel()
+}
+
+func (k *streamKiller) release() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	close(k.gate)
+	k.gate = nil
+}
+
+// waitFor returns the events received until one matches, inclusive.
+func (k *streamKiller) waitFor(match func(*chatterbox.Event) bool) []*chatterbox.Event {
+	k.t.Helper()
+	var ret []*chatterbox.Event
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case evt := <-k.events:
+			ret = append(ret, evt)
+			if match(evt) {
+				return ret
+			}
+		case <-timeout:
+			k.t.Fatalf("timed out waiting for event; got: %v", ret)
+		}
+	}
+}
+
+type recordingStream struct {
+	grpc.ClientStream
+	events chan<- *chatterbox.Event
+}
+
+func (s *recordingStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if evt, ok := m.(*chatterbox.Event); ok && err == nil {
+		s.events <- evt
+	}
+	return err
+}
+
+func isChat(text string) func(*chatterbox.Event) bool {
+	return func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_CHAT && evt.Text == text
+	}
+}
+
+func isInitialized(evt *chatterbox.Event) bool {
+	return evt.What == chatterbox.What_INITIALIZED
+}
+
+// startServer starts an in-memory server, returning a func to dial it.
+func startServer(t *testing.T, opts chatserver.Options, svrOpts ...grpc.ServerOption) func(opts ...grpc.DialOption) *grpc.ClientConn {
+	return startChatServer(t, chatserver.NewServer(opts), svrOpts...)
+}
+
+func startChatServer(t *testing.T, chat *chatserver.Server, svrOpts ...grpc.ServerOption) func(opts ...grpc.DialOption) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	svr := grpc.NewServer(svrOpts...)
+	chatterbox.RegisterChatterBoxServer(svr, chat)
+	go func() {
+		_ = svr.Serve(lis)
+	}()
+	t.Cleanup(svr.Stop)
+
+	return func(opts ...grpc.DialOption) *grpc.ClientConn {
+		opts = append(opts,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		conn, err := grpc.Dial("bufnet", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		return conn
+	}
+}
+
+// rawClient is a participant driven directly by the test.
+type rawClient struct {
+	t      *testing.T
+	stream chatterbox.ChatterBox_ChatClient
+}
+
+func joinRoom(ctx context.Context, t *testing.T, conn *grpc.ClientConn, room, nick string) *rawClient {
+	rc, _ := joinRoomWithSnapshot(ctx, t, conn, room, nick)
+	return rc
+}
+
+// joinRoomWithSnapshot joins a room, also returning the initial events through INITIALIZED.
+func joinRoomWithSnapshot(ctx context.Context, t *testing.T, conn *grpc.ClientConn, room, nick string) (*rawClient, []*chatterbox.Event) {
+	stream, err := chatterbox.NewChatterBoxClient(conn).Chat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&chatterbox.Send{Room: room, Nick: nick}); err != nil {
+		t.Fatal(err)
+	}
+	rc := &rawClient{t: t, stream: stream}
+	return rc, rc.waitFor(isInitialized)
+}
+
+func (rc *rawClient) send(msg *chatterbox.Send) {
+	if err := rc.stream.Send(msg); err != nil {
+		rc.t.Fatal(err)
+	}
+}
+
+func (rc *rawClient) say(text string) {
+	if err := rc.stream.Send(&chatterbox.Send{Text: text}); err != nil {
+		rc.t.Fatal(err)
+	}
+	rc.waitFor(isChat(text)) // until it has been published
+}
+
+// waitFor returns the events received until one matches, inclusive.
+func (rc *rawClient) waitFor(match func(*chatterbox.Event) bool) []*chatterbox.Event {
+	var ret []*chatterbox.Event
+	for {
+		evt, err := rc.stream.Recv()
+		if err != nil {
+			rc.t.Fatal(err)
+		}
+		ret = append(ret, evt)
+		if match(evt) {
+			return ret
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{HistorySize: 3})
+	alice := joinRoom(ctx, t, dial(), "test", "alice")
+	for i := 1; i <= 5; i++ {
+		alice.say(fmt.Sprint(i))
+	}
+
+	// The snapshot is the members, then the most recent chats marked as history, oldest first, then INITIALIZED.
+	_, snapshot := joinRoomWithSnapshot(ctx, t, dial(), "test", "bob")
+	var members, history []string
+	for i, evt := range snapshot[:len(snapshot)-1] {
+		switch {
+		case evt.What == chatterbox.What_JOIN && len(history) == 0:
+			members = append(members, evt.Who)
+		case evt.What == chatterbox.What_CHAT && evt.History && evt.Who == "alice":
+			history = append(history, evt.Text)
+		default:
+			t.Errorf("unexpected event %d in snapshot: %v", i, evt)
+		}
+	}
+	if fmt.Sprint(members) != "[alice bob]" {
+		t.Errorf("got members: %q, want: [alice bob]", members)
+	}
+	if fmt.Sprint(history) != "[3 4 5]" {
+		t.Errorf("got history: %q, want: [3 4 5]", history)
+	}
+
+	// Live chats are not marked as history.
+	alice.send(&chatterbox.Send{Text: "live"})
+	if got := alice.waitFor(isChat("live")); got[len(got)-1].History {
+		t.Errorf("got: %v, want a live chat", got[len(got)-1])
+	}
+}
+
+// fastReconnect makes clients reconnect quickly for the rest of the test.
+func fastReconnect(t *testing.T) {
+	saved := reconnectPolicy
+	reconnectPolicy = errgroup.Policy{InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Retryable: retryable}
+	t.Cleanup(func() {
+		reconnectPolicy = saved
+	})
+}
+
+func testReconnect(t *testing.T, opts chatserver.Options) (missed, after []*chatterbox.Event, members []string) {
+	fastReconnect(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, opts)
+	k := newStreamKiller(t)
+	mc := &MembersClient{
+		cl:        chatterbox.NewChatterBoxClient(dial(grpc.WithStreamInterceptor(k.intercept))),
+		room:      "test",
+		nick:      "alice",
+		chatInput: make(chan *chatterbox.Send),
+	}
+	if err := mc.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	k.waitFor(isInitialized)
+
+	bob := joinRoom(ctx, t, dial(), "test", "bob")
+	bob.say("before")
+	k.waitFor(isChat("before"))
+
+	// Kill alice's stream, and chat while she is disconnected.
+	k.kill()
+	bob.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_LEAVE && evt.Who == "alice"
+	})
+	bob.say("missed")
+	k.release()
+
+	missed = k.waitFor(func(evt *chatterbox.Event) bool {
+		return isInitialized(evt) && evt.Seq != 0
+	})
+	bob.say("after")
+	after = k.waitFor(isChat("after"))
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return missed, after, mc.members.Strings()
+}
+
+func TestClientResume(t *testing.T) {
+	missed, after, members := testReconnect(t, chatserver.Options{})
+
+	// Only the missed events were replayed, including the chat.
+	initialized := missed[len(missed)-1]
+	if !initialized.Resumed {
+		t.Fatalf("expected to resume, got: %v", missed)
+	}
+	var chats []string
+	for _, evt := range missed {
+		if evt.What == chatterbox.What_CHAT {
+			chats = append(chats, evt.Text)
+			if evt.History {
+				t.Errorf("resumed event was marked as history: %v", evt)
+			}
+		}
+		if evt.What == chatterbox.What_JOIN && evt.Who == "bob" {
+			t.Errorf("bob's membership was resent: %v", evt)
+		}
+	}
+	if len(chats) != 1 || chats[0] != "missed" {
+		t.Errorf("got chats: %q, want: [missed]", chats)
+	}
+	if got := after[len(after)-1].Seq; got <= initialized.Seq {
+		t.Errorf("live event seq %d is not after resumed seq %d", got, initialized.Seq)
+	}
+	if len(members) != 2 || members[0] != "alice" || members[1] != "bob" {
+		t.Errorf("got members: %q, want: [alice bob]", members)
+	}
+}
+
+func TestClientResumeFallback(t *testing.T) {
+	missed, _, members := testReconnect(t, chatserver.Options{ResumeWindow: -1})
+
+	// With no events retained, the client starts over from a full snapshot, including history.
+	if missed[len(missed)-1].Resumed {
+		t.Fatalf("expected a snapshot, got: %v", missed)
+	}
+	var history []string
+	for _, evt := range missed {
+		if evt.What == chatterbox.What_CHAT && evt.History {
+			history = append(history, evt.Text)
+		}
+	}
+	if len(history) == 0 || history[len(history)-1] != "missed" {
+		t.Errorf("got history: %q, want it to end with: missed", history)
+	}
+	if len(members) != 2 || members[0] != "alice" || members[1] != "bob" {
+		t.Errorf("got members: %q, want: [alice bob]", members)
+	}
+}
+
+func TestRooms(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{})
+	alice := joinRoom(ctx, t, dial(), "a", "alice")
+	monitor, err := chatterbox.NewChatterBoxClient(dial()).Monitor(ctx, &chatterbox.MonitorRequest{Room: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, snapshot := joinRoomWithSnapshot(ctx, t, dial(), "b", "bob")
+
+	// Bob does not see alice, in another room.
+	for _, evt := range snapshot {
+		if evt.Room != "b" || evt.Who == "alice" {
+			t.Errorf("got: %v in room b's snapshot", evt)
+		}
+	}
+	bob.send(&chatterbox.Send{Text: "in b"})
+	alice.say("in a")
+	for _, evt := range bob.waitFor(isChat("in b")) {
+		if evt.Room != "b" {
+			t.Errorf("got: %v in room b", evt)
+		}
+	}
+
+	// Nor does the monitor of room a see bob.
+	for {
+		evt, err := monitor.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evt.Room != "a" || evt.Who == "bob" {
+			t.Errorf("got: %v monitoring room a", evt)
+		}
+		if isChat("in a")(evt) {
+			break
+		}
+	}
+
+	// A message in the first Send is chatted once its sender has joined.
+	dave, err := chatterbox.NewChatterBoxClient(dial()).Chat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dave.Send(&chatterbox.Send{Room: "a", Nick: "dave", Text: "hi all"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := alice.waitFor(isChat("hi all")); got[len(got)-1].Who != "dave" {
+		t.Errorf("got: %v, want a chat from dave", got[len(got)-1])
+	}
+
+	// Room names must be valid, whether joining or monitoring.
+	expectCode(ctx, t, dial(), codes.InvalidArgument, &chatterbox.Send{Room: strings.Repeat("a", chatserver.MaxRoomLength+1)})
+	badMonitor, err := chatterbox.NewChatterBoxClient(dial()).Monitor(ctx, &chatterbox.MonitorRequest{Room: "bad\nroom"})
+	if err == nil {
+		_, err = badMonitor.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got: %v, want InvalidArgument", err)
+	}
+}
+
+func TestRoomReaping(t *testing.T) {
+	// rejoin joins the room once its only member has left and it has been empty for wait, reporting whether
+	// its history survived.
+	rejoin := func(ctx context.Context, t *testing.T, dial func(...grpc.DialOption) *grpc.ClientConn, wait time.Duration) bool {
+		aliceCtx, aliceCancel := context.WithCancel(ctx)
+		alice := joinRoom(aliceCtx, t, dial(), "test", "alice")
+		alice.say("hello")
+		aliceCancel()
+
+		join := func() []*chatterbox.Event {
+			bobCtx, bobCancel := context.WithCancel(ctx)
+			defer bobCancel()
+			_, snapshot := joinRoomWithSnapshot(bobCtx, t, dial(), "test", "bob")
+			return snapshot
+		}
+		isAlice := func(evt *chatterbox.Event) bool {
+			return evt.What == chatterbox.What_JOIN && evt.Who == "alice"
+		}
+		for containsEvent(join(), isAlice) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		time.Sleep(wait)
+		return containsEvent(join(), isChat("hello"))
+	}
+
+	t.Run("kept", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		dial := startServer(t, chatserver.Options{IdleTimeout: time.Hour})
+		if !rejoin(ctx, t, dial, 100*time.Millisecond) {
+			t.Error("empty room was reaped before the idle timeout")
+		}
+	})
+	t.Run("reaped", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		dial := startServer(t, chatserver.Options{IdleTimeout: 20 * time.Millisecond})
+		if rejoin(ctx, t, dial, 100*time.Millisecond) {
+			t.Error("empty room was not reaped after the idle timeout")
+		}
+	})
+}
+
+func containsEvent(events []*chatterbox.Event, match func(*chatterbox.Event) bool) bool {
+	for _, evt := range events {
+		if match(evt) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNicknames(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{})
+	alice := joinRoom(ctx, t, dial(), "a", "alice")
+	bob := joinRoom(ctx, t, dial(), "b", "bob")
+
+	// Nicknames are unique across rooms, and must be valid.
+	expectCode(ctx, t, dial(), codes.AlreadyExists, &chatterbox.Send{Room: "a", Nick: "bob"})
+	expectCode(ctx, t, dial(), codes.InvalidArgument, &chatterbox.Send{Room: "a", Nick: " bob"})
+
+	// Renames are published to the room.
+	alice.send(&chatterbox.Send{Nick: "carol"})
+	got := alice.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_RENAME
+	})
+	if rename := got[len(got)-1]; rename.Who != "alice" || rename.Text != "carol" || rename.Room != "a" {
+		t.Errorf("got: %v, want alice renamed to carol", rename)
+	}
+	alice.send(&chatterbox.Send{Text: "hi"})
+	if got := alice.waitFor(isChat("hi")); got[len(got)-1].Who != "carol" {
+		t.Errorf("got: %v, want a chat from carol", got[len(got)-1])
+	}
+	joinRoom(ctx, t, dial(), "a", "alice") // alice is free again
+
+	// Renaming to a nickname in use is reported to the member, who keeps their name, and the rest of the message is
+	// dropped.
+	bob.send(&chatterbox.Send{Nick: "carol", Text: "as carol"})
+	got = bob.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_ERROR
+	})
+	if e := got[len(got)-1]; e.Room != "b" || !strings.Contains(e.Text, "carol") {
+		t.Errorf("got: %v, want an error about carol", e)
+	}
+	bob.send(&chatterbox.Send{Text: "still bob"})
+	for _, evt := range bob.waitFor(isChat("still bob")) {
+		if isChat("as carol")(evt) {
+			t.Errorf("got: %v after a failed rename", evt)
+		} else if isChat("still bob")(evt) && evt.Who != "bob" {
+			t.Errorf("got: %v, want a chat from bob", evt)
+		}
+	}
+}
+
+func TestDirectMessages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{})
+	alice := joinRoom(ctx, t, dial(), "test", "alice")
+	bob := joinRoom(ctx, t, dial(), "other", "bob")
+	carol := joinRoom(ctx, t, dial(), "test", "carol")
+
+	// DMs reach their recipient in any room, and nobody else.
+	alice.send(&chatterbox.Send{To: "bob", Text: "psst"})
+	got := bob.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_DM
+	})
+	if dm := got[len(got)-1]; dm.Who != "alice" || dm.To != "bob" || dm.Text != "psst" || dm.Seq != 0 {
+		t.Errorf("got DM: %v", dm)
+	}
+	alice.say("public")
+	for _, evt := range carol.waitFor(isChat("public")) {
+		if evt.What == chatterbox.What_DM {
+			t.Errorf("carol got a DM meant for bob: %v", evt)
+		}
+	}
+
+	// A DM to nobody is reported to its sender alone, whose stream continues.
+	alice.send(&chatterbox.Send{To: "nobody", Text: "hello?"})
+	got = alice.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_ERROR
+	})
+	if e := got[len(got)-1]; !strings.Contains(e.Text, "nobody") {
+		t.Errorf("got: %v, want an error about nobody", e)
+	}
+	alice.say("still here")
+	for _, evt := range carol.waitFor(isChat("still here")) {
+		if evt.What == chatterbox.What_ERROR {
+			t.Errorf("carol got an error meant for alice: %v", evt)
+		}
+	}
+}
+
+func TestPresence(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{})
+	alice := joinRoom(ctx, t, dial(), "test", "alice")
+	bob := joinRoom(ctx, t, dial(), "test", "bob")
+	isPresence := func(p chatterbox.Presence) func(*chatterbox.Event) bool {
+		return func(evt *chatterbox.Event) bool {
+			return evt.What == chatterbox.What_PRESENCE && evt.Who == "alice" && evt.Presence == p
+		}
+	}
+
+	alice.send(&chatterbox.Send{Presence: chatterbox.Presence_AWAY.Enum()})
+	bob.waitFor(isPresence(chatterbox.Presence_AWAY))
+
+	// New members see the presence of existing members.
+	_, snapshot := joinRoomWithSnapshot(ctx, t, dial(), "test", "carol")
+	for _, evt := range snapshot {
+		if evt.What == chatterbox.What_JOIN && evt.Who == "alice" && evt.Presence != chatterbox.Presence_AWAY {
+			t.Errorf("got: %v, want alice away", evt)
+		}
+	}
+
+	// Sending a message ends typing.
+	alice.send(&chatterbox.Send{Presence: chatterbox.Presence_TYPING.Enum()})
+	bob.waitFor(isPresence(chatterbox.Presence_TYPING))
+	alice.say("done")
+	bob.waitFor(isPresence(chatterbox.Presence_ACTIVE))
+}
+
+// testToken is like TokenCredentials, but may be sent over bufconn, which is insecure.
+type testToken string
+
+func (t testToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t testToken) RequireTransportSecurity() bool {
+	return false
+}
+
+func withToken(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(testToken(token))
+}
+
+// expectCode sends msgs on a new Chat stream, and expects it to end with the given code.
+func expectCode(ctx context.Context, t *testing.T, conn *grpc.ClientConn, want codes.Code, msgs ...*chatterbox.Send) {
+	t.Helper()
+	stream, err := chatterbox.NewChatterBoxClient(conn).Chat(ctx)
+	for _, msg := range msgs {
+		if err == nil {
+			err = stream.Send(msg)
+		}
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != want {
+		t.Errorf("got: %v, want %s", err, want)
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	auth := &chatserver.Auth{Tokens: map[string]string{"secret": "alice"}}
+	dial := startServer(t, chatserver.Options{}, grpc.StreamInterceptor(auth.StreamInterceptor))
+
+	// The principal's nickname is used instead of a generated one.
+	_, snapshot := joinRoomWithSnapshot(ctx, t, dial(withToken("secret")), "test", "")
+	if joined := snapshot[0]; joined.What != chatterbox.What_JOIN || joined.Who != "alice" {
+		t.Errorf("got: %v, want alice to join", joined)
+	}
+
+	expectCode(ctx, t, dial(), codes.Unauthenticated, &chatterbox.Send{Room: "test"})
+	expectCode(ctx, t, dial(withToken("wrong")), codes.Unauthenticated, &chatterbox.Send{Room: "test"})
+	expectCode(ctx, t, dial(withToken("secret")), codes.PermissionDenied, &chatterbox.Send{Room: "test", Nick: "mallory"})
+}
+
+func TestModeration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	auth := &chatserver.Auth{Tokens: map[string]string{"a": "admin", "b": "alice", "c": "bob"}}
+	dial := startServer(t, chatserver.Options{Admins: []string{"admin"}},
+		grpc.StreamInterceptor(auth.StreamInterceptor), grpc.UnaryInterceptor(auth.UnaryInterceptor))
+	admin := chatterbox.NewChatterBoxClient(dial(withToken("a")))
+	alice := joinRoom(ctx, t, dial(withToken("b")), "test", "")
+	bob := joinRoom(ctx, t, dial(withToken("c")), "test", "")
+
+	// Only admins may kick.
+	_, err := chatterbox.NewChatterBoxClient(dial(withToken("c"))).Kick(ctx, &chatterbox.KickRequest{Nick: "alice"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("got: %v, want PermissionDenied", err)
+	}
+
+	if _, err := admin.Kick(ctx, &chatterbox.KickRequest{Nick: "alice", Reason: "spam", Ban: true}); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := alice.stream.Recv(); err != nil {
+			if status.Code(err) != codes.PermissionDenied {
+				t.Errorf("got: %v, want PermissionDenied", err)
+			}
+			break
+		}
+	}
+	got := bob.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_LEAVE && evt.Who == "alice"
+	})
+	if len(got) < 2 {
+		t.Fatalf("got: %v, want BAN then LEAVE", got)
+	}
+	if ban := got[len(got)-2]; ban.What != chatterbox.What_BAN || ban.Who != "alice" || ban.By != "admin" || ban.Text != "spam" {
+		t.Errorf("got: %v, want alice banned by admin", ban)
+	}
+
+	// Alice may not come back until unbanned.
+	expectCode(ctx, t, dial(withToken("b")), codes.PermissionDenied, &chatterbox.Send{Room: "test"})
+	if _, err := admin.Unban(ctx, &chatterbox.UnbanRequest{Nick: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	joinRoom(ctx, t, dial(withToken("b")), "test", "")
+}
+
+func TestClientKicked(t *testing.T) {
+	fastReconnect(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	auth := &chatserver.Auth{Tokens: map[string]string{"a": "admin", "b": "alice", "c": "bob"}}
+	dial := startServer(t, chatserver.Options{Admins: []string{"admin"}},
+		grpc.StreamInterceptor(auth.StreamInterceptor), grpc.UnaryInterceptor(auth.UnaryInterceptor))
+	bob := joinRoom(ctx, t, dial(withToken("c")), "test", "")
+	mc := &MembersClient{
+		cl:        chatterbox.NewChatterBoxClient(dial(withToken("b"))),
+		room:      "test",
+		chatInput: make(chan *chatterbox.Send),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- mc.Run(ctx)
+	}()
+	bob.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_JOIN && evt.Who == "alice"
+	})
+
+	// A kicked client stops, rather than rejoining, although it is not banned.
+	if _, err := chatterbox.NewChatterBoxClient(dial(withToken("a"))).Kick(ctx, &chatterbox.KickRequest{Nick: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("got: %v, want PermissionDenied", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("kicked client is still running")
+	}
+	bob.send(&chatterbox.Send{Text: "alone"})
+	for _, evt := range bob.waitFor(isChat("alone")) {
+		if evt.What == chatterbox.What_JOIN && evt.Who == "alice" {
+			t.Errorf("got: %v after alice was kicked", evt)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{RateLimit: 0.001, RateBurst: 3, MaxTextLength: 5})
+
+	expectCode(ctx, t, dial(), codes.InvalidArgument, &chatterbox.Send{Room: "test"}, &chatterbox.Send{Text: "too long"})
+
+	msgs := []*chatterbox.Send{{Room: "test"}}
+	for i := 0; i < 4; i++ {
+		msgs = append(msgs, &chatterbox.Send{Text: "hi"})
+	}
+	expectCode(ctx, t, dial(), codes.ResourceExhausted, msgs...)
+}
+
+func TestServerShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	chat := chatserver.NewServer(chatserver.Options{})
+	dial := startChatServer(t, chat)
+	alice := joinRoom(ctx, t, dial(), "a", "alice")
+	monitor, err := chatterbox.NewChatterBoxClient(dial()).Monitor(ctx, &chatterbox.MonitorRequest{Room: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		evt, err := monitor.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isInitialized(evt) {
+			break
+		}
+	}
+
+	chat.Shutdown("bye", 3*time.Second)
+
+	// Every stream gets the notice, then ends cleanly.
+	for _, stream := range []commonClientStream{alice.stream, monitor} {
+		var last *chatterbox.Event
+		for {
+			evt, err := stream.Recv()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			last = evt
+		}
+		if last == nil || last.What != chatterbox.What_SERVER_SHUTDOWN || last.Text != "bye" || last.RetryAfterMillis != 3000 {
+			t.Errorf("got last event: %v, want SERVER_SHUTDOWN", last)
+		}
+	}
+
+	// New streams are turned away.
+	expectCode(ctx, t, dial(), codes.Unavailable, &chatterbox.Send{Room: "a"})
+}
+
+func TestClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	dial := startServer(t, chatserver.Options{RateLimit: -1, MaxTextLength: -1})
+	bob := joinRoom(ctx, t, dial(), "test", "bob")
+
+	// Alice sends as fast as she can without reading, so the server is sending to her, and she is
+	// sending to the server, when she goes away.
+	aliceCtx, aliceCancel := context.WithCancel(ctx)
+	alice := joinRoom(aliceCtx, t, dial(), "test", "alice")
+	g := errgroup.New(ctx)
+	g.Go(func(context.Context) error {
+		for {
+			if err := alice.stream.Send(&chatterbox.Send{Text: "spam"}); err != nil {
+				return nil
+			}
+		}
+	})
+	bob.waitFor(isChat("spam"))
+	aliceCancel()
+	_ = g.Wait()
+
+	// Bob sees her leave exactly once, and nothing from her afterwards.
+	bob.waitFor(func(evt *chatterbox.Event) bool {
+		return evt.What == chatterbox.What_LEAVE && evt.Who == "alice"
+	})
+	bob.send(&chatterbox.Send{Text: "anyone?"})
+	for _, evt := range bob.waitFor(isChat("anyone?")) {
+		if evt.Who == "alice" {
+			t.Errorf("got: %v after alice left", evt)
+		}
+	}
+
+	// Her nickname is free again.
+	joinRoom(ctx, t, dial(), "test", "alice")
+}
@@ -8,20 +8,13 @@ import (
 	"time"
 
 	"github.com/fullstorydev/go/examples/chatterbox"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
-const (
-	// Because clients run infinite retry loops, they should exponentially backoff when servers are unavailable.
-	// This might be much higher in a real system
-	maxBackoff = 8 * time.Second
-	minBackoff = time.Second
-)
-
-// RunMonitor is an example of a gRPC client for a one-way server stream.
-func RunMonitor(ctx context.Context, cl chatterbox.ChatterBoxClient) error {
+// RunMonitor is an example of a gRPC client for a one-way server stream, monitoring the given room.
+func RunMonitor(ctx context.Context, room string, cl chatterbox.ChatterBoxClient) error {
 	mm := &MembersMonitor{
-		cl: cl,
+		cl:   cl,
+		room: room,
 	}
 
 	if err := mm.Start(ctx); err != nil {
@@ -32,7 +25,7 @@ func RunMonitor(ctx context.Context, cl chatterbox.ChatterBoxClient) error {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 	for {
-		log.Printf("Members: %s", mm.GetMembers())
+		log.Printf("Members of %s: %s", room, mm.GetMembers())
 
 		select {
 		case <-ctx.Done():
@@ -43,9 +36,11 @@ func RunMonitor(ctx context.Context, cl chatterbox.ChatterBoxClient) error {
 }
 
 type MembersMonitor struct {
-	cl chatterbox.ChatterBoxClient
+	cl   chatterbox.ChatterBoxClient
+	room string
 
 	mu      sync.RWMutex
+	lastSeq int64 // the seq of the last event seen, to resume from when reconnecting
 	members chatterbox.MembersModel
 }
 
@@ -75,11 +70,11 @@ func (mm *MembersMonitor) Start(ctx context.Context) error {
 	started = true
 
 	go func() {
-		// Monitor the first stream until it dies.
-		if err := mm.monitorStream(ctx, stream); err != nil {
-			log.Println(err)
-		}
-		if ctx.Err() != nil {
+		// Monitor the first stream until it dies, then wait if the server asked us to.
+		if _, err := waitForServer(ctx, mm.monitorStream(ctx, stream)); err != nil {
+			if ctx.Err() == nil {
+				log.Println(err) // the server will not have us back
+			}
 			return
 		}
 		// Run until ctx is cancelled.
@@ -90,9 +85,10 @@ func (mm *MembersMonitor) Start(ctx context.Context) error {
 	return nil
 }
 
-// Run runs this MembersMonitor in the foreground until ctx is cancelled.
+// Run runs this MembersMonitor in the foreground until ctx is cancelled, or until the server will not have it
+// back, such as when its room name is invalid, returning the error which ended its last stream.
 func (mm *MembersMonitor) Run(ctx context.Context) error {
-	backoff := minBackoff
+	backoff := reconnectPolicy.NewBackoff()
 
 	// Loop forever until killed or cancelled.
 	for {
@@ -107,34 +103,36 @@ func (mm *MembersMonitor) Run(ctx context.Context) error {
 
 			return true, mm.monitorStream(ctx, stream)
 		}()
+		waited, err := waitForServer(ctx, err)
 		if err != nil {
-			log.Println(err)
-		}
-
-		if ok {
-			backoff = minBackoff
-		} else {
-			backoff *= 2
-			if backoff > maxBackoff {
-				backoff = maxBackoff
+			if ctx.Err() != nil {
+				return nil
 			}
+			return err
 		}
 
-		select {
-		case <-ctx.Done():
+		if ok || waited {
+			backoff.Reset()
+		}
+		if waited {
+			continue // the server told us how long to wait
+		}
+		if err := backoff.Wait(ctx); err != nil {
 			return nil
-		case <-time.After(backoff):
 		}
 	}
 }
 
 func (mm *MembersMonitor) startStream(ctx context.Context) (chatterbox.ChatterBox_MonitorClient, error) {
-	stream, err := mm.cl.Monitor(ctx, &emptypb.Empty{})
+	mm.mu.RLock()
+	lastSeq, members := mm.lastSeq, mm.members
+	mm.mu.RUnlock()
+	stream, err := mm.cl.Monitor(ctx, &chatterbox.MonitorRequest{Room: mm.room, ResumeAfter: lastSeq})
 	if err != nil {
 		return nil, fmt.Errorf("cl.Monitor: %w", err)
 	}
 
-	members, err := fetchInitialState(ctx, stream)
+	members, seq, err := fetchInitialState(ctx, stream, members)
 	if err != nil {
 		return nil, fmt.Errorf("fetchInitialState: %w", err)
 	}
@@ -144,6 +142,7 @@ func (mm *MembersMonitor) startStream(ctx context.Context) (chatterbox.ChatterBo
 		mm.mu.Lock()
 		defer mm.mu.Unlock()
 		mm.members = members
+		mm.lastSeq = seq
 	}()
 	return stream, nil
 }
@@ -157,25 +156,16 @@ func (mm *MembersMonitor) monitorStream(_ context.Context, stream chatterbox.Cha
 			return filterClientError(err)
 		}
 
-		switch msg.What {
-		case chatterbox.What_CHAT:
-			log.Printf("%s: %s", msg.Who, msg.Text)
-		case chatterbox.What_JOIN:
-			func() {
-				mm.mu.Lock()
-				defer mm.mu.Unlock()
-				mm.members.Add(msg.Who)
-			}()
-			log.Printf("%s: joined", msg.Who)
-		case chatterbox.What_LEAVE:
-			func() {
-				mm.mu.Lock()
-				defer mm.mu.Unlock()
-				mm.members.Remove(msg.Who)
-			}()
-			log.Printf("%s: left", msg.Who)
-		default:
-			return fmt.Errorf("unexpected type: %s", msg.What)
+		if err := func() error {
+			mm.mu.Lock()
+			defer mm.mu.Unlock()
+			mm.lastSeq = msg.Seq
+			if err := applyEvent(mm.members, msg); err != nil {
+				return err
+			}
+			return checkShutdown(msg)
+		}(); err != nil {
+			return err
 		}
 	}
 }
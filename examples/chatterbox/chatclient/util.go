@@ -2,15 +2,40 @@ package chatclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/rand"
+	"time"
 
+	"github.com/fullstorydev/go/errgroup"
 	"github.com/fullstorydev/go/examples/chatterbox"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// Because clients run infinite retry loops, they should exponentially backoff when servers are unavailable.
+// This might be much higher in a real system.
+var reconnectPolicy = errgroup.Policy{
+	InitialBackoff: time.Second,
+	MaxBackoff:     8 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+	Retryable:      retryable,
+}
+
+// retryable reports whether a stream which ended with err is worth reconnecting. It is not if the server
+// refused the member, such as when they were kicked or their nickname is taken, since it would only do so again.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated, codes.InvalidArgument, codes.AlreadyExists:
+		return false
+	}
+	return true
+}
+
 // filterClientError cleans up error logging by filtering out errors related to (probably user initiated) cancel.
 func filterClientError(err error) error {
 	if err == io.EOF || err == context.Canceled {
@@ -22,28 +47,141 @@ func filterClientError(err error) error {
 	return err
 }
 
+// serverShutdownError ends a stream when the server announces that it is shutting down.
+type serverShutdownError struct {
+	retryAfter time.Duration
+}
+
+func (e *serverShutdownError) Error() string {
+	return fmt.Sprintf("server shut down; reconnect after %s", e.retryAfter)
+}
+
+// checkShutdown returns a serverShutdownError for a SERVER_SHUTDOWN event, which ends the stream.
+func checkShutdown(msg *chatterbox.Event) error {
+	if msg.What != chatterbox.What_SERVER_SHUTDOWN {
+		return nil
+	}
+	return &serverShutdownError{retryAfter: time.Duration(msg.RetryAfterMillis) * time.Millisecond}
+}
+
+// waitForServer logs why a stream ended. If the server announced that it was shutting down, it waits as
+// long as the server suggested before returning true, adding jitter so that clients do not all reconnect
+// at once. Returns ctx.Err() if ctx is done first, or err itself if reconnectPolicy says it is not retryable.
+func waitForServer(ctx context.Context, err error) (bool, error) {
+	var shutdown *serverShutdownError
+	if !errors.As(err, &shutdown) {
+		if err != nil && reconnectPolicy.Retryable != nil && !reconnectPolicy.Retryable(err) {
+			return false, err
+		}
+		if err != nil {
+			log.Println(err)
+		}
+		return false, ctx.Err()
+	}
+
+	delay := shutdown.retryAfter + time.Duration(rand.Float64()*reconnectPolicy.Jitter*float64(shutdown.retryAfter))
+	log.Printf("Reconnecting in %s", delay.Round(time.Millisecond))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-timer.C:
+		return true, nil
+	}
+}
+
 // commonClientStream intersects ChatterBox_ChatClient and ChatterBox_MonitorClient
 type commonClientStream interface {
 	grpc.ClientStream
 	Recv() (*chatterbox.Event, error)
 }
 
-// fetchInitialState ensures we read a complete initial model from the server
-func fetchInitialState(ctx context.Context, stream commonClientStream) (chatterbox.MembersModel, error) {
+// fetchInitialState ensures we read a complete initial model from the server, printing any chats along the way.
+// If the server resumed from lastSeq, it sent only the missed events, which are applied to a copy of members.
+// Returns the new model and the sequence number of the last event it reflects.
+func fetchInitialState(ctx context.Context, stream commonClientStream, members chatterbox.MembersModel) (chatterbox.MembersModel, int64, error) {
 	// Wait for the initial state to come back.
-	members := chatterbox.MembersModel{}
+	var events []*chatterbox.Event
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
-			return nil, fmt.Errorf("stream.Recv: %w", err)
+			return nil, 0, fmt.Errorf("stream.Recv: %w", err)
+		}
+		if err := checkShutdown(msg); err != nil {
+			return nil, 0, err // before we even got started
 		}
-		switch msg.What {
-		case chatterbox.What_INITIALIZED:
-			return members, nil
-		case chatterbox.What_JOIN:
-			members.Add(msg.Who)
-		default:
-			return nil, fmt.Errorf("unexpected type: %s", msg.What)
+		if msg.What != chatterbox.What_INITIALIZED {
+			events = append(events, msg)
+			continue
 		}
+
+		ret := chatterbox.MembersModel{}
+		if msg.Resumed {
+			for name := range members {
+				ret.Add(name)
+			}
+		}
+		for _, evt := range events {
+			if !msg.Resumed && evt.What != chatterbox.What_JOIN && !evt.History {
+				return nil, 0, fmt.Errorf("unexpected type before %s: %s", chatterbox.What_INITIALIZED, evt.What)
+			}
+			if err := applyEvent(ret, evt); err != nil {
+				return nil, 0, err
+			}
+		}
+		return ret, msg.Seq, nil
+	}
+}
+
+// applyEvent applies an event to the members model, logging it unless it is part of a snapshot.
+func applyEvent(members chatterbox.MembersModel, msg *chatterbox.Event) error {
+	switch msg.What {
+	case chatterbox.What_CHAT:
+		if msg.History {
+			log.Printf("[history] %s: %s", msg.Who, msg.Text)
+		} else {
+			log.Printf("%s: %s", msg.Who, msg.Text)
+		}
+	case chatterbox.What_JOIN:
+		members.Add(msg.Who)
+		if msg.Seq != 0 {
+			log.Printf("%s: joined", msg.Who)
+		}
+		if msg.Presence != chatterbox.Presence_ACTIVE {
+			logPresence(msg)
+		}
+	case chatterbox.What_LEAVE:
+		members.Remove(msg.Who)
+		log.Printf("%s: left", msg.Who)
+	case chatterbox.What_RENAME:
+		members.Rename(msg.Who, msg.Text)
+		log.Printf("%s: is now known as %s", msg.Who, msg.Text)
+	case chatterbox.What_DM:
+		log.Printf("[dm] %s: %s", msg.Who, msg.Text)
+	case chatterbox.What_PRESENCE:
+		logPresence(msg)
+	case chatterbox.What_KICK:
+		log.Printf("%s: was kicked by %s: %s", msg.Who, msg.By, msg.Text)
+	case chatterbox.What_BAN:
+		log.Printf("%s: was banned by %s: %s", msg.Who, msg.By, msg.Text)
+	case chatterbox.What_SERVER_SHUTDOWN:
+		log.Printf("Server is shutting down: %s", msg.Text)
+	case chatterbox.What_ERROR:
+		log.Printf("Error: %s", msg.Text)
+	default:
+		return fmt.Errorf("unexpected type: %s", msg.What)
+	}
+	return nil
+}
+
+func logPresence(msg *chatterbox.Event) {
+	switch msg.Presence {
+	case chatterbox.Presence_ACTIVE:
+		log.Printf("%s: is back", msg.Who)
+	case chatterbox.Presence_AWAY:
+		log.Printf("%s: is away", msg.Who)
+	case chatterbox.Presence_TYPING:
+		log.Printf("%s: is typing", msg.Who)
 	}
 }
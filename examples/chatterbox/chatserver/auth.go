@@ -0,0 +1,118 @@
+package chatserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Auth authenticates every call, by bearer token or by verified client certificate, and maps the
+// authenticated principal to the nickname they chat as. Install it with grpc.StreamInterceptor and
+// grpc.UnaryInterceptor.
+type Auth struct {
+	// Tokens maps each valid bearer token to the nickname of its holder.
+	Tokens map[string]string
+	// ClientCerts accepts clients presenting a verified TLS certificate, as the nickname in its common name.
+	// The server must be configured to verify client certificates, for mutual TLS.
+	ClientCerts bool
+}
+
+type nickKey struct{}
+
+// NickFromContext returns the nickname of the principal authenticated by Auth, if any.
+func NickFromContext(ctx context.Context) (string, bool) {
+	nick, ok := ctx.Value(nickKey{}).(string)
+	return nick, ok
+}
+
+// StreamInterceptor rejects streams which are not authenticated, with an Unauthenticated status.
+func (a *Auth) StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	nick, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{
+		ServerStream: ss,
+		ctx:          context.WithValue(ss.Context(), nickKey{}, nick),
+	})
+}
+
+// UnaryInterceptor rejects calls which are not authenticated, with an Unauthenticated status.
+func (a *Auth) UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	nick, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, nickKey{}, nick), req)
+}
+
+func (a *Auth) authenticate(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get("authorization"); len(vals) > 0 {
+		token := strings.TrimPrefix(vals[0], "Bearer ")
+		if nick, ok := a.Tokens[token]; ok && token != vals[0] {
+			return nick, nil
+		}
+		return "", status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if a.ClientCerts {
+		if p, ok := peer.FromContext(ctx); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+				nick := info.State.VerifiedChains[0][0].Subject.CommonName
+				if err := validateNick(nick); err != nil {
+					return "", status.Errorf(codes.Unauthenticated, "client certificate: %s", status.Convert(err).Message())
+				}
+				return nick, nil
+			}
+		}
+	}
+	return "", status.Error(codes.Unauthenticated, "no credentials")
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// LoadTokens reads a token file for Auth.Tokens. Each line holds a token, a space, and the nickname of its
+// holder; blank lines and lines beginning with # are ignored.
+func LoadTokens(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ret := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected a token and a nickname", path, lineNum)
+		}
+		token, nick := line[:i], strings.TrimSpace(line[i+1:])
+		if err := validateNick(nick); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, lineNum, status.Convert(err).Message())
+		}
+		ret[token] = nick
+	}
+	return ret, scanner.Err()
+}
@@ -0,0 +1,86 @@
+package chatserver
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BanList is the set of banned nicknames, optionally persisted to a file with one nickname per line.
+type BanList struct {
+	path string // empty if not persisted
+
+	mu     sync.Mutex
+	banned map[string]struct{}
+}
+
+// OpenBanList loads the ban list from path, which need not exist yet. An empty path gives a ban list
+// which is kept in memory only.
+func OpenBanList(path string) (*BanList, error) {
+	b := &BanList{path: path, banned: map[string]struct{}{}}
+	if path == "" {
+		return b, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if nick := strings.TrimSpace(scanner.Text()); nick != "" {
+			b.banned[nick] = struct{}{}
+		}
+	}
+	return b, scanner.Err()
+}
+
+// Contains returns whether the nickname is banned.
+func (b *BanList) Contains(nick string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.banned[nick]
+	return ok
+}
+
+// Add bans a nickname.
+func (b *BanList) Add(nick string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.banned[nick] = struct{}{}
+	return b.saveLocked()
+}
+
+// Remove lifts the ban on a nickname, returning false if it was not banned.
+func (b *BanList) Remove(nick string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.banned[nick]; !ok {
+		return false, nil
+	}
+	delete(b.banned, nick)
+	return true, b.saveLocked()
+}
+
+// saveLocked rewrites the file, if any, so that it is replaced whole or not at all; the caller must hold the lock.
+func (b *BanList) saveLocked() error {
+	if b.path == "" {
+		return nil
+	}
+	nicks := make([]string, 0, len(b.banned))
+	for nick := range b.banned {
+		nicks = append(nicks, nick+"\n")
+	}
+	sort.Strings(nicks)
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(nicks, "")), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
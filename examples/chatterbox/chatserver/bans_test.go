@@ -0,0 +1,31 @@
+package chatserver
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBanList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.txt")
+	b, err := OpenBanList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Add("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Remove("bob"); !ok || err != nil {
+		t.Fatalf("got: %v, %v; want bob unbanned", ok, err)
+	}
+
+	b, err = OpenBanList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Contains("alice") || b.Contains("bob") {
+		t.Errorf("got alice: %v, bob: %v; want only alice banned", b.Contains("alice"), b.Contains("bob"))
+	}
+}
@@ -0,0 +1,44 @@
+package chatserver
+
+import (
+	"time"
+)
+
+const (
+	// DefaultRateLimit is the number of messages per second each member may send, on average.
+	DefaultRateLimit = 5
+	// DefaultRateBurst is the number of messages each member may send at once.
+	DefaultRateBurst = 10
+	// DefaultMaxTextLength is the maximum length of a message, in runes.
+	DefaultMaxTextLength = 1000
+)
+
+// rateLimiter is a token bucket, which fills at rate tokens per second up to burst tokens. It requires
+// external synchronization.
+type rateLimiter struct {
+	rate   float64 // zero means unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int, now time.Time) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// allow takes a token if one is available, returning false if not.
+func (l *rateLimiter) allow(now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
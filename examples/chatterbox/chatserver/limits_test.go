@@ -0,0 +1,36 @@
+package chatserver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(2, 3, now)
+	for i := 0; i < 3; i++ {
+		if !l.allow(now) {
+			t.Fatalf("message %d denied within burst", i)
+		}
+	}
+	if l.allow(now) {
+		t.Fatal("message allowed beyond burst")
+	}
+	if !l.allow(now.Add(500 * time.Millisecond)) {
+		t.Fatal("message denied after refill")
+	}
+	if l.allow(now.Add(500 * time.Millisecond)) {
+		t.Fatal("message allowed beyond refill")
+	}
+
+	// The bucket never holds more than the burst.
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !l.allow(later) {
+			t.Fatalf("message %d denied within burst", i)
+		}
+	}
+	if l.allow(later) {
+		t.Fatal("message allowed beyond burst")
+	}
+}
@@ -1,47 +1,165 @@
 package chatserver
 
 import (
+	"log"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/fullstorydev/go/eventstream"
 	"github.com/fullstorydev/go/examples/chatterbox"
 )
 
-// ServerMembers is a server-side Log Replicated Model tracking changes to MembersModel over time.
+// ServerMembers is a server-side Log Replicated Model tracking changes to MembersModel over time,
+// for a single room.
+//
+// It also retains recent events of any kind for resuming clients, by holding on to an earlier Promise from
+// its EventStream: every event published since that point remains reachable from it. Chat history is kept
+// apart, as a slice of the most recent CHAT events, since a Promise held at the oldest of them would keep
+// every later event reachable too, without bound in a room which has little chat.
 type ServerMembers struct {
-	mu      sync.RWMutex
-	members chatterbox.MembersModel
-	es      eventstream.EventStream
+	room     string
+	mu       sync.RWMutex
+	members  chatterbox.MembersModel
+	presence map[string]chatterbox.Presence // members who are not ACTIVE
+	es       eventstream.EventStream
+	seq      int64 // the sequence number of the last published event
+	closed   bool  // set once the EventStream is closed, after which nothing more is published
+
+	historySize   int                 // the number of recent CHAT events to replay to new clients
+	history       []*chatterbox.Event // the most recent CHAT events, oldest first, at most historySize
+	resumeWindow  int                 // the number of recent events of any kind to retain for resuming clients
+	retained      eventstream.Promise // the oldest retained event
+	retainedCount int                 // the number of events retained, at most resumeWindow
+
+	store *Store // persists CHAT events, if non-nil
 }
 
-func NewMembersList() *ServerMembers {
-	return &ServerMembers{
-		members: chatterbox.MembersModel{},
-		es:      eventstream.New(),
+// NewMembersList creates the model for a room. If store is non-nil, the room's history is loaded from it, and
+// subsequent chat messages are persisted to it.
+func NewMembersList(room string, historySize, resumeWindow int, store *Store) *ServerMembers {
+	es := eventstream.New()
+	m := &ServerMembers{
+		room:     room,
+		members:  chatterbox.MembersModel{},
+		presence: map[string]chatterbox.Presence{},
+		es:       es,
+		// Sequence numbers start from the current time, so that they keep increasing across server
+		// restarts and reaped rooms, and a client cannot resume from another incarnation of the room.
+		seq:          time.Now().UnixNano(),
+		historySize:  historySize,
+		resumeWindow: resumeWindow,
+		retained:     es.Subscribe(),
+		store:        store,
 	}
+	if store != nil && historySize > 0 {
+		// Nobody is subscribed yet, so this only seeds the retained events.
+		for _, evt := range store.History(room) {
+			m.publish(evt)
+		}
+	}
+	return m
+}
+
+// Name returns the name of the room.
+func (m *ServerMembers) Name() string {
+	return m.room
 }
 
-func (m *ServerMembers) ReadAndSubscribe() ([]string, eventstream.Promise) {
+// ReadAndSubscribe returns a JOIN event for each current member, carrying their presence, and recent
+// CHAT events, oldest first, and the sequence number of the last event they reflect, along with a Promise
+// for all subsequent events.
+func (m *ServerMembers) ReadAndSubscribe() ([]*chatterbox.Event, []*chatterbox.Event, int64, eventstream.Promise) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	ret := make([]string, 0, len(m.members))
+	names := make([]string, 0, len(m.members))
 	for k := range m.members {
-		ret = append(ret, k)
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	joins := make([]*chatterbox.Event, 0, len(names))
+	for _, name := range names {
+		joins = append(joins, &chatterbox.Event{
+			Who:      name,
+			What:     chatterbox.What_JOIN,
+			Room:     m.room,
+			Presence: m.presence[name],
+		})
+	}
+
+	history := append([]*chatterbox.Event(nil), m.history...)
+	return joins, history, m.seq, m.es.Subscribe()
+}
+
+// Resume returns the events published after the given sequence number, and the sequence number of the
+// last one, along with a Promise for all subsequent events. If any of those events are no longer
+// retained, ok is false and the client must start over from ReadAndSubscribe.
+func (m *ServerMembers) Resume(after int64) (events []*chatterbox.Event, seq int64, p eventstream.Promise, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.resumeWindow == 0 {
+		return nil, 0, nil, false
+	}
+	if oldest := m.seq - int64(m.retainedCount) + 1; after < oldest-1 || after > m.seq {
+		return nil, 0, nil, false
+	}
+
+	m.forEachRetained(func(evt *chatterbox.Event) {
+		if evt.Seq > after {
+			events = append(events, evt)
+		}
+	})
+	return events, m.seq, m.es.Subscribe(), true
+}
+
+// forEachRetained calls fn with each retained event, oldest first; the caller must hold the lock.
+func (m *ServerMembers) forEachRetained(fn func(*chatterbox.Event)) {
+	// Every retained event is ready, since publishers hold the write lock.
+	p := m.retained
+	for i := 0; i < m.retainedCount; i++ {
+		var v interface{}
+		v, p = p.Next()
+		fn(v.(*chatterbox.Event))
+	}
+}
+
+// publish publishes an event and updates the retained events, unless the stream is closed; the caller
+// must hold the write lock.
+func (m *ServerMembers) publish(evt *chatterbox.Event) {
+	if m.closed {
+		return
+	}
+	m.seq++
+	evt.Seq = m.seq
+	m.es.Publish(evt)
+	if evt.What == chatterbox.What_CHAT && m.historySize > 0 {
+		if len(m.history) == m.historySize {
+			copy(m.history, m.history[1:])
+			m.history = m.history[:len(m.history)-1]
+		}
+		m.history = append(m.history, evt)
+	}
+
+	// Let go of the oldest events beyond the resume window.
+	m.retainedCount++
+	for m.retainedCount > m.resumeWindow {
+		_, m.retained = m.retained.Next()
+		m.retainedCount--
 	}
-	sort.Strings(ret)
-	return ret, m.es.Subscribe()
 }
 
-func (m *ServerMembers) Join(name string) {
+func (m *ServerMembers) Join(name string, presence chatterbox.Presence) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// apply update, publish event
 	m.members.Add(name)
-	m.es.Publish(&chatterbox.Event{
-		Who:  name,
-		What: chatterbox.What_JOIN,
+	m.setPresence(name, presence)
+	m.publish(&chatterbox.Event{
+		Who:      name,
+		What:     chatterbox.What_JOIN,
+		Room:     m.room,
+		Presence: presence,
 	})
 }
 
@@ -51,16 +169,116 @@ func (m *ServerMembers) Leave(name string) {
 
 	// apply update, publish event
 	m.members.Remove(name)
-	m.es.Publish(&chatterbox.Event{
+	delete(m.presence, name)
+	m.publish(&chatterbox.Event{
 		Who:  name,
 		What: chatterbox.What_LEAVE,
+		Room: m.room,
 	})
 }
 
+// Rename renames a member, returning false if they are not a member.
+func (m *ServerMembers) Rename(oldName, newName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.members[oldName]; !ok {
+		return false
+	}
+
+	// apply update, publish event
+	m.members.Rename(oldName, newName)
+	if p, ok := m.presence[oldName]; ok {
+		delete(m.presence, oldName)
+		m.presence[newName] = p
+	}
+	m.publish(&chatterbox.Event{
+		Who:  oldName,
+		What: chatterbox.What_RENAME,
+		Text: newName,
+		Room: m.room,
+	})
+	return true
+}
+
+// SetPresence changes the presence of a member, publishing an event if it changed.
+func (m *ServerMembers) SetPresence(name string, presence chatterbox.Presence) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.members[name]; !ok || m.presence[name] == presence {
+		return
+	}
+
+	// apply update, publish event
+	m.setPresence(name, presence)
+	m.publish(&chatterbox.Event{
+		Who:      name,
+		What:     chatterbox.What_PRESENCE,
+		Room:     m.room,
+		Presence: presence,
+	})
+}
+
+// setPresence records the presence of a member; the caller must hold the write lock.
+func (m *ServerMembers) setPresence(name string, presence chatterbox.Presence) {
+	if presence == chatterbox.Presence_ACTIVE {
+		delete(m.presence, name)
+	} else {
+		m.presence[name] = presence
+	}
+}
+
+// Shutdown publishes a final event, such as SERVER_SHUTDOWN, and closes the EventStream, so that every
+// subscriber sees the event and then the end of the stream. The model is still updated afterwards, but
+// nothing more is published.
+func (m *ServerMembers) Shutdown(evt *chatterbox.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	evt.Room = m.room
+	m.publish(evt)
+	m.es.Close()
+	m.closed = true
+}
+
+// Moderate publishes a moderation event, such as KICK or BAN.
+func (m *ServerMembers) Moderate(evt *chatterbox.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	evt.Room = m.room
+	m.publish(evt)
+}
+
 func (m *ServerMembers) Chat(name string, text string) {
-	m.es.Publish(&chatterbox.Event{
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return // nobody would see it
+	}
+
+	// Sending a message means they are done typing.
+	if m.presence[name] == chatterbox.Presence_TYPING {
+		m.setPresence(name, chatterbox.Presence_ACTIVE)
+		m.publish(&chatterbox.Event{
+			Who:      name,
+			What:     chatterbox.What_PRESENCE,
+			Room:     m.room,
+			Presence: chatterbox.Presence_ACTIVE,
+		})
+	}
+
+	evt := &chatterbox.Event{
 		Who:  name,
 		What: chatterbox.What_CHAT,
 		Text: text,
-	})
+		Room: m.room,
+	}
+	m.publish(evt)
+	if m.store != nil {
+		// Appending under the lock keeps the log in the same order as the stream.
+		if err := m.store.Append(evt); err != nil {
+			log.Printf("persisting chat in %s: %s", m.room, err)
+		}
+	}
 }
@@ -0,0 +1,43 @@
+package chatserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fullstorydev/go/examples/chatterbox"
+)
+
+func TestServerMembersRetention(t *testing.T) {
+	m := NewMembersList("test", 3, 100, nil)
+	m.Join("alice", chatterbox.Presence_ACTIVE)
+	for i := 0; i < 5; i++ {
+		m.Chat("alice", fmt.Sprint(i))
+	}
+
+	// Presence changes without chat must not keep older events, which are retained for history, alive.
+	for i := 0; i < 10000; i++ {
+		m.SetPresence("alice", chatterbox.Presence(1+i%2)) // AWAY, TYPING, ...
+	}
+	if m.retainedCount != 100 {
+		t.Errorf("got %d events retained, want 100", m.retainedCount)
+	}
+
+	// History still holds the most recent chats, oldest first.
+	_, history, _, _ := m.ReadAndSubscribe()
+	var got []string
+	for _, evt := range history {
+		got = append(got, evt.Text)
+	}
+	if fmt.Sprint(got) != "[2 3 4]" {
+		t.Errorf("got history: %q, want: [2 3 4]", got)
+	}
+
+	// Events beyond the resume window cannot be resumed from, but recent ones can.
+	if _, _, _, ok := m.Resume(m.seq - 101); ok {
+		t.Error("resumed from beyond the resume window")
+	}
+	events, _, _, ok := m.Resume(m.seq - 100)
+	if !ok || len(events) != 100 {
+		t.Errorf("got %d events, %v, want 100 events", len(events), ok)
+	}
+}
@@ -0,0 +1,118 @@
+package chatserver
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fullstorydev/go/examples/chatterbox"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxNickLength is the maximum length of a nickname, in runes.
+const MaxNickLength = 32
+
+// InboxSize is the number of direct messages which may be queued for a member before more are dropped.
+const InboxSize = 100
+
+// Member is a connected member, reachable by nickname wherever they are.
+type Member interface {
+	// Deliver queues a direct message for the member, returning false if it was dropped.
+	Deliver(evt *chatterbox.Event) bool
+	// Kick disconnects the member, publishing evt, a KICK or BAN filled in with who was kicked, to their room.
+	Kick(evt *chatterbox.Event)
+}
+
+// Nicks tracks the nicknames in use across all rooms, so that each is unique, along with the member
+// using each.
+type Nicks struct {
+	mu     sync.Mutex
+	inUse  map[string]Member
+	lastId int64
+}
+
+func NewNicks() *Nicks {
+	return &Nicks{
+		inUse: map[string]Member{},
+	}
+}
+
+// Reserve claims the given nickname for a member, returning an AlreadyExists status if it is in use,
+// or an InvalidArgument status if it is not a valid nickname.
+func (n *Nicks) Reserve(nick string, m Member) error {
+	if err := validateNick(nick); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.inUse[nick]; ok {
+		return status.Errorf(codes.AlreadyExists, "nickname %q is already taken", nick)
+	}
+	n.inUse[nick] = m
+	return nil
+}
+
+// ReserveNext makes up and claims a nickname which is not in use, for a member.
+func (n *Nicks) ReserveNext(m Member) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for {
+		n.lastId++
+		nick := fmt.Sprintf("User %d", n.lastId)
+		if _, ok := n.inUse[nick]; !ok {
+			n.inUse[nick] = m
+			return nick
+		}
+	}
+}
+
+// Release gives up a nickname claimed by Reserve or ReserveNext.
+func (n *Nicks) Release(nick string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.inUse, nick)
+}
+
+// Lookup returns the member using the given nickname, returning a NotFound status if there is none.
+func (n *Nicks) Lookup(nick string) (Member, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	m, ok := n.inUse[nick]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no member is named %q", nick)
+	}
+	return m, nil
+}
+
+// Deliver queues a direct message for the member it is addressed to, returning a NotFound status if
+// there is no such member. If their inbox is full, the message is dropped.
+func (n *Nicks) Deliver(evt *chatterbox.Event) error {
+	m, err := n.Lookup(evt.To)
+	if err != nil {
+		return err
+	}
+	if !m.Deliver(evt) {
+		log.Printf("dropping direct message from %s to %s: inbox is full", evt.Who, evt.To)
+	}
+	return nil
+}
+
+func validateNick(nick string) error {
+	switch {
+	case nick == "":
+		return status.Error(codes.InvalidArgument, "nickname must not be empty")
+	case utf8.RuneCountInString(nick) > MaxNickLength:
+		return status.Errorf(codes.InvalidArgument, "nickname must be at most %d characters", MaxNickLength)
+	case strings.TrimSpace(nick) != nick:
+		return status.Error(codes.InvalidArgument, "nickname must not begin or end with spaces")
+	case strings.IndexFunc(nick, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0:
+		return status.Error(codes.InvalidArgument, "nickname must be printable")
+	}
+	return nil
+}
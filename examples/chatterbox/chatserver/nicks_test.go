@@ -0,0 +1,62 @@
+package chatserver
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNicksReserve(t *testing.T) {
+	n := NewNicks()
+	if err := n.Reserve("alice", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Reserve("alice", nil); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("got: %v, want AlreadyExists", err)
+	}
+	if err := n.Reserve("", nil); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got: %v, want InvalidArgument", err)
+	}
+
+	// Made up nicknames skip those in use.
+	if err := n.Reserve("User 1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.ReserveNext(nil); got != "User 2" {
+		t.Errorf("got: %q, want: User 2", got)
+	}
+
+	// Released nicknames may be reserved again.
+	n.Release("alice")
+	if err := n.Reserve("alice", nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateNick(t *testing.T) {
+	for _, tc := range []struct {
+		nick string
+		ok   bool
+	}{
+		{"alice", true},
+		{"Alice Smith", true},
+		{"ålice", true},
+		{strings.Repeat("a", MaxNickLength), true},
+		{strings.Repeat("å", MaxNickLength), true},
+		{"", false},
+		{strings.Repeat("a", MaxNickLength+1), false},
+		{" alice", false},
+		{"alice ", false},
+		{"ali\nce", false},
+		{"ali\x00ce", false},
+	} {
+		err := validateNick(tc.nick)
+		if tc.ok && err != nil {
+			t.Errorf("%q: got: %v, want ok", tc.nick, err)
+		} else if !tc.ok && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%q: got: %v, want InvalidArgument", tc.nick, err)
+		}
+	}
+}
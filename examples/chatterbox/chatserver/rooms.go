@@ -0,0 +1,149 @@
+package chatserver
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fullstorydev/go/examples/chatterbox"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultIdleTimeout is how long an empty room is kept before it is reaped.
+	DefaultIdleTimeout = 5 * time.Minute
+	// DefaultHistorySize is the number of recent chat messages each room replays to new clients.
+	DefaultHistorySize = 20
+	// DefaultResumeWindow is the number of recent events each room retains for resuming clients.
+	DefaultResumeWindow = 1000
+	// MaxRoomLength is the maximum length of a room name, in runes.
+	MaxRoomLength = 64
+)
+
+// Room is a single chat room, with its own members model and event stream.
+type Room struct {
+	*ServerMembers
+
+	refs int         // number of connected clients and monitors; guarded by Rooms.mu
+	idle *time.Timer // reaps the room once it has been empty for the idle timeout; guarded by Rooms.mu
+}
+
+// Rooms manages the set of rooms. Rooms are created lazily when first joined or monitored, and reaped
+// once they have been empty for the idle timeout.
+type Rooms struct {
+	idleTimeout  time.Duration
+	historySize  int
+	resumeWindow int
+	store        *Store
+
+	mu     sync.Mutex
+	rooms  map[string]*Room
+	closed bool
+}
+
+func NewRooms(idleTimeout time.Duration, historySize, resumeWindow int, store *Store) *Rooms {
+	return &Rooms{
+		idleTimeout:  idleTimeout,
+		historySize:  historySize,
+		resumeWindow: resumeWindow,
+		store:        store,
+		rooms:        map[string]*Room{},
+	}
+}
+
+// Acquire returns the named room, creating it if necessary, or an Unavailable status after Shutdown, or an
+// InvalidArgument status if it is not a valid room name. The caller must Release the room when done.
+func (rs *Rooms) Acquire(name string) (*Room, error) {
+	if name == "" {
+		name = chatterbox.DefaultRoom
+	} else if err := validateRoom(name); err != nil {
+		return nil, err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.acquireLocked(name)
+}
+
+// acquireLocked implements Acquire; the caller must hold the lock.
+func (rs *Rooms) acquireLocked(name string) (*Room, error) {
+	if rs.closed {
+		return nil, status.Error(codes.Unavailable, "server is shutting down")
+	}
+	r := rs.rooms[name]
+	if r == nil {
+		r = &Room{
+			ServerMembers: NewMembersList(name, rs.historySize, rs.resumeWindow, rs.store),
+		}
+		rs.rooms[name] = r
+		log.Printf("room %s created", name)
+	}
+	if r.idle != nil {
+		r.idle.Stop()
+		r.idle = nil
+	}
+	r.refs++
+	return r, nil
+}
+
+// Release gives up a room returned by Acquire, scheduling it to be reaped if it is now empty.
+func (rs *Rooms) Release(r *Room) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r.refs--
+	if r.refs > 0 || rs.closed {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(rs.idleTimeout, func() {
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		if r.idle != timer {
+			return // re-acquired since
+		}
+		delete(rs.rooms, r.room)
+		r.es.Close()
+		log.Printf("room %s reaped", r.room)
+	})
+	r.idle = timer
+}
+
+// Shutdown publishes evt to every room as its final event, and closes their EventStreams. Rooms cannot be
+// acquired afterwards.
+func (rs *Rooms) Shutdown(evt *chatterbox.Event) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.closed = true
+	for _, r := range rs.rooms {
+		if r.idle != nil {
+			r.idle.Stop()
+			r.idle = nil
+		}
+		r.Shutdown(proto.Clone(evt).(*chatterbox.Event))
+	}
+}
+
+// Len returns the number of rooms, including empty rooms which have not been reaped yet.
+func (rs *Rooms) Len() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return len(rs.rooms)
+}
+
+func validateRoom(room string) error {
+	switch {
+	case utf8.RuneCountInString(room) > MaxRoomLength:
+		return status.Errorf(codes.InvalidArgument, "room name must be at most %d characters", MaxRoomLength)
+	case strings.TrimSpace(room) != room:
+		return status.Error(codes.InvalidArgument, "room name must not begin or end with spaces")
+	case strings.IndexFunc(room, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0:
+		return status.Error(codes.InvalidArgument, "room name must be printable")
+	}
+	return nil
+}
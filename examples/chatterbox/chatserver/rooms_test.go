@@ -0,0 +1,73 @@
+package chatserver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoomsReacquire(t *testing.T) {
+	rs := NewRooms(time.Millisecond, 0, 0, nil)
+	r, err := rs.Acquire("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.Release(r)
+
+	// Let the reap timer fire while we hold the lock, so that it is still waiting for the lock when the room
+	// is acquired again.
+	rs.mu.Lock()
+	time.Sleep(20 * time.Millisecond)
+	again, err := rs.acquireLocked("test")
+	rs.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != r {
+		t.Fatal("room was reaped before its idle timeout")
+	}
+
+	// The stale timer must not reap the room, which is held again.
+	time.Sleep(20 * time.Millisecond)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.rooms["test"] != r {
+		t.Error("held room was reaped")
+	}
+}
+
+func TestValidateRoom(t *testing.T) {
+	for _, tc := range []struct {
+		room string
+		ok   bool
+	}{
+		{"lobby", true},
+		{"Room 101", true},
+		{"café", true},
+		{strings.Repeat("é", MaxRoomLength), true},
+		{strings.Repeat("a", MaxRoomLength+1), false},
+		{" lobby", false},
+		{"lobby ", false},
+		{"lob\nby", false},
+		{"lob\x00by", false},
+	} {
+		err := validateRoom(tc.room)
+		if tc.ok && err != nil {
+			t.Errorf("%q: got: %v, want ok", tc.room, err)
+		} else if !tc.ok && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%q: got: %v, want InvalidArgument", tc.room, err)
+		}
+	}
+
+	// Invalid rooms are not created.
+	rs := NewRooms(time.Minute, 0, 0, nil)
+	if _, err := rs.Acquire("bad\x00room"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got: %v, want InvalidArgument", err)
+	}
+	if rs.Len() != 0 {
+		t.Errorf("got %d rooms, want none", rs.Len())
+	}
+}
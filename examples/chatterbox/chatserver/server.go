@@ -1,68 +1,435 @@
 package chatserver
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
-	"sync/atomic"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/fullstorydev/go/errgroup"
+	"github.com/fullstorydev/go/eventstream"
 	"github.com/fullstorydev/go/examples/chatterbox"
 	"google.golang.org/grpc"
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// Options configures a Server. The zero value uses the defaults.
+type Options struct {
+	// IdleTimeout is how long an empty room is kept before it is reaped. Zero means DefaultIdleTimeout.
+	IdleTimeout time.Duration
+	// HistorySize is the number of recent chat messages each room replays to new clients.
+	// Zero means DefaultHistorySize; a negative value disables history.
+	HistorySize int
+	// ResumeWindow is the number of recent events each room retains for reconnecting clients to resume from.
+	// Zero means DefaultResumeWindow; a negative value disables resuming.
+	ResumeWindow int
+	// Store persists chat messages, and provides the history of rooms as they are created. Nil means history
+	// is kept in memory only, and lost when the server restarts or a room is reaped.
+	Store *Store
+	// RateLimit is the number of messages per second each member may send, on average, beyond which their
+	// stream ends with a ResourceExhausted status. Zero means DefaultRateLimit; a negative value means no limit.
+	RateLimit float64
+	// RateBurst is the number of messages each member may send at once. Zero means DefaultRateBurst.
+	RateBurst int
+	// MaxTextLength is the maximum length of a message in runes, beyond which the sender's stream ends with an
+	// InvalidArgument status. Zero means DefaultMaxTextLength; a negative value means no limit.
+	MaxTextLength int
+	// Bans is the list of banned nicknames. Nil means an empty list, kept in memory only.
+	Bans *BanList
+	// Admins are the nicknames of the authenticated principals who may kick and ban.
+	Admins []string
+}
+
 type Server struct {
 	chatterbox.UnimplementedChatterBoxServer
 
-	model  *ServerMembers
-	lastId int64
+	rooms *Rooms
+	nicks *Nicks
+	bans  *BanList
+
+	admins        map[string]bool
+	rateLimit     float64
+	rateBurst     int
+	maxTextLength int
 }
 
-func NewServer() *Server {
+func NewServer(opts Options) *Server {
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	if opts.HistorySize == 0 {
+		opts.HistorySize = DefaultHistorySize
+	} else if opts.HistorySize < 0 {
+		opts.HistorySize = 0
+	}
+	if opts.ResumeWindow == 0 {
+		opts.ResumeWindow = DefaultResumeWindow
+	} else if opts.ResumeWindow < 0 {
+		opts.ResumeWindow = 0
+	}
+	if opts.RateLimit == 0 {
+		opts.RateLimit = DefaultRateLimit
+	} else if opts.RateLimit < 0 {
+		opts.RateLimit = 0
+	}
+	if opts.RateBurst <= 0 {
+		opts.RateBurst = DefaultRateBurst
+	}
+	if opts.MaxTextLength == 0 {
+		opts.MaxTextLength = DefaultMaxTextLength
+	}
+	if opts.Bans == nil {
+		opts.Bans, _ = OpenBanList("") // never fails
+	}
+	admins := map[string]bool{}
+	for _, nick := range opts.Admins {
+		admins[nick] = true
+	}
 	return &Server{
-		model:  NewMembersList(),
-		lastId: 0,
+		rooms:         NewRooms(opts.IdleTimeout, opts.HistorySize, opts.ResumeWindow, opts.Store),
+		nicks:         NewNicks(),
+		bans:          opts.Bans,
+		admins:        admins,
+		rateLimit:     opts.RateLimit,
+		rateBurst:     opts.RateBurst,
+		maxTextLength: opts.MaxTextLength,
 	}
 }
 
 var _ chatterbox.ChatterBoxServer = (*Server)(nil)
 
 func (s *Server) Chat(server chatterbox.ChatterBox_ChatServer) error {
-	// Make up a name for this connection.
-	id := atomic.AddInt64(&s.lastId, 1)
-	name := fmt.Sprintf("User %d", id)
+	// The first message is the handshake, selecting the room and nickname.
+	req, err := server.Recv()
+	if err != nil {
+		return filterServerError(err)
+	}
+	name := req.Nick
+	authNick, authenticated := NickFromContext(server.Context())
+	if authenticated {
+		// Authenticated members chat as the nickname of their principal.
+		if name != "" && name != authNick {
+			return status.Errorf(codes.PermissionDenied, "authenticated as %q", authNick)
+		}
+		name = authNick
+	}
+
+	// The session's context ends when it is kicked, or when the send loop ends.
+	ctx, cancel := context.WithCancelCause(server.Context())
+	defer cancel(nil)
+	cs := &chatSession{
+		Server:        s,
+		inbox:         make(chan *chatterbox.Event, InboxSize),
+		cancel:        cancel,
+		authenticated: authenticated,
+		limiter:       newRateLimiter(s.rateLimit, s.rateBurst, time.Now()),
+	}
+	room, err := s.rooms.Acquire(req.Room)
+	if err != nil {
+		return err
+	}
+	defer s.rooms.Release(room)
+	if name == "" {
+		name = s.nicks.ReserveNext(cs)
+	} else if s.bans.Contains(name) {
+		return status.Errorf(codes.PermissionDenied, "%q is banned", name)
+	} else if err := s.nicks.Reserve(name, cs); err != nil {
+		return err
+	}
 
 	// Join the memberslist.
-	s.model.Join(name)
-	log.Printf("%s joined", name)
-	defer log.Printf("%s left", name)
-	defer s.model.Leave(name)
-
-	// We do not wait on the recv loop to exit; it will exit after we return.
-	go func() {
-		if err := s.recvLoop(name, server); err != nil {
-			log.Printf("%s err: %s", name, err)
-		}
-	}()
+	cs.room, cs.name = room, name
+	room.Join(name, req.GetPresence())
+	log.Printf("%s joined %s", name, room.Name())
+	defer cs.leave()
 
-	// Run the send loop in the foreground.
-	return s.sendLoop(server)
+	// Both loops run until either fails or panics, or the send loop ends; the first error ends the stream.
+	// A client which stops sending may keep listening, so the recv loop ending cleanly does not end it.
+	g := errgroup.New(ctx)
+	g.Go(func(ctx context.Context) error {
+		return cs.recvLoop(ctx, req, server)
+	})
+	g.Go(func(ctx context.Context) error {
+		defer cancel(nil) // stop the recv loop
+		return s.sendLoop(ctx, room, req.ResumeAfter, cs.inbox, server)
+	})
+	if err := g.Wait(); err != nil {
+		var pe *errgroup.PanicError
+		if errors.As(err, &pe) {
+			log.Printf("%s: %+v", cs.Name(), pe)
+			return status.Error(codes.Internal, "internal error")
+		}
+		log.Printf("%s err: %s", cs.Name(), err)
+		return err
+	}
+	return nil
 }
 
-func (s *Server) Monitor(_ *emptypb.Empty, server chatterbox.ChatterBox_MonitorServer) error {
+func (s *Server) Monitor(req *chatterbox.MonitorRequest, server chatterbox.ChatterBox_MonitorServer) error {
 	// Don't join, just monitor.
-	return s.sendLoop(server)
+	room, err := s.rooms.Acquire(req.Room)
+	if err != nil {
+		return err
+	}
+	defer s.rooms.Release(room)
+	return s.sendLoop(server.Context(), room, req.ResumeAfter, nil, server)
+}
+
+// Shutdown tells every client that the server is shutting down and when to reconnect, with a
+// SERVER_SHUTDOWN event in each room, and ends their streams. New streams fail with an Unavailable status.
+// Call it before grpc.Server.GracefulStop, which waits for streams to end.
+func (s *Server) Shutdown(reason string, retryAfter time.Duration) {
+	log.Printf("shutting down: %s", reason)
+	s.rooms.Shutdown(&chatterbox.Event{
+		What:             chatterbox.What_SERVER_SHUTDOWN,
+		Text:             reason,
+		RetryAfterMillis: retryAfter.Milliseconds(),
+	})
+}
+
+// Kick disconnects a member, and bans their nickname if requested.
+func (s *Server) Kick(ctx context.Context, req *chatterbox.KickRequest) (*chatterbox.KickResponse, error) {
+	admin, err := s.checkAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Nick == "" {
+		return nil, status.Error(codes.InvalidArgument, "nickname must not be empty")
+	}
+
+	what := chatterbox.What_KICK
+	if req.Ban {
+		what = chatterbox.What_BAN
+		if err := s.bans.Add(req.Nick); err != nil {
+			return nil, status.Errorf(codes.Internal, "saving ban list: %s", err)
+		}
+		log.Printf("%s banned %s: %s", admin, req.Nick, req.Reason)
+	}
+	m, err := s.nicks.Lookup(req.Nick)
+	if err != nil {
+		if req.Ban {
+			return &chatterbox.KickResponse{}, nil // banning someone who is not connected is fine
+		}
+		return nil, err
+	}
+	m.Kick(&chatterbox.Event{What: what, Text: req.Reason, By: admin})
+	return &chatterbox.KickResponse{}, nil
+}
+
+// Unban lifts a ban.
+func (s *Server) Unban(ctx context.Context, req *chatterbox.UnbanRequest) (*chatterbox.UnbanResponse, error) {
+	admin, err := s.checkAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.bans.Remove(req.Nick)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "saving ban list: %s", err)
+	}
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%q is not banned", req.Nick)
+	}
+	log.Printf("%s unbanned %s", admin, req.Nick)
+	return &chatterbox.UnbanResponse{}, nil
+}
+
+// checkAdmin returns the nickname of the admin making the call, or a PermissionDenied status if the caller
+// is not an admin.
+func (s *Server) checkAdmin(ctx context.Context) (string, error) {
+	nick, ok := NickFromContext(ctx)
+	if !ok || !s.admins[nick] {
+		return "", status.Error(codes.PermissionDenied, "only admins may moderate")
+	}
+	return nick, nil
+}
+
+// chatSession is the state of a single member of a room, connected via Chat.
+type chatSession struct {
+	*Server
+	room          *Room
+	inbox         chan *chatterbox.Event // direct messages to the member
+	cancel        context.CancelCauseFunc
+	authenticated bool         // if set, the member's nickname is fixed by their principal
+	limiter       *rateLimiter // only used by recvLoop
+
+	mu     sync.Mutex
+	name   string
+	left   bool
+	kicked *chatterbox.Event // the KICK or BAN to publish when leaving, if any
+}
+
+var _ Member = (*chatSession)(nil)
+
+// Deliver implements Member.
+func (cs *chatSession) Deliver(evt *chatterbox.Event) bool {
+	select {
+	case cs.inbox <- evt:
+		return true
+	default:
+		return false
+	}
+}
+
+// Kick implements Member, publishing evt to the member's room as they leave.
+func (cs *chatSession) Kick(evt *chatterbox.Event) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.left || cs.kicked != nil {
+		return
+	}
+	cs.kicked = evt
+	verb := "kicked"
+	if evt.What == chatterbox.What_BAN {
+		verb = "banned"
+	}
+	cs.cancel(status.Errorf(codes.PermissionDenied, "%s by %s: %s", verb, evt.By, evt.Text))
+}
+
+// Name returns the current nickname of the member.
+func (cs *chatSession) Name() string {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.name
+}
+
+// rename changes the member's nickname, if newName is available.
+func (cs *chatSession) rename(newName string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.left || newName == cs.name {
+		return nil
+	}
+	if cs.authenticated {
+		return status.Errorf(codes.PermissionDenied, "authenticated as %q", cs.name)
+	}
+	if cs.bans.Contains(newName) {
+		return status.Errorf(codes.PermissionDenied, "%q is banned", newName)
+	}
+	if err := cs.nicks.Reserve(newName, cs); err != nil {
+		return err
+	}
+	cs.room.Rename(cs.name, newName)
+	cs.nicks.Release(cs.name)
+	log.Printf("%s is now known as %s", cs.name, newName)
+	cs.name = newName
+	return nil
+}
+
+// leave removes the member from the room, and releases their nickname, once.
+func (cs *chatSession) leave() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.left {
+		return
+	}
+	cs.left = true
+	if cs.kicked != nil {
+		cs.kicked.Who = cs.name
+		cs.room.Moderate(cs.kicked)
+		log.Printf("%s was %s by %s: %s", cs.name, strings.ToLower(cs.kicked.What.String()), cs.kicked.By, cs.kicked.Text)
+	}
+	cs.room.Leave(cs.name)
+	cs.nicks.Release(cs.name)
+	log.Printf("%s left %s", cs.name, cs.room.Name())
 }
 
-func (s *Server) recvLoop(name string, server chatterbox.ChatterBox_ChatServer) error {
+// reportError tells the member, and only them, that their request failed, without ending their stream.
+func (cs *chatSession) reportError(err error) {
+	msg := status.Convert(err).Message()
+	if !cs.Deliver(&chatterbox.Event{What: chatterbox.What_ERROR, Text: msg, Room: cs.room.Name()}) {
+		log.Printf("dropping error for %s: inbox is full", cs.Name())
+	}
+	log.Printf("%s err: %s", cs.Name(), msg)
+}
+
+// recvLoop handles any message in the handshake, then messages from the member until they stop sending,
+// returning nil, or until ctx is done.
+func (cs *chatSession) recvLoop(ctx context.Context, handshake *chatterbox.Send, server chatterbox.ChatterBox_ChatServer) error {
+	if handshake.Text != "" {
+		// The rest of the handshake has already been applied by joining.
+		if err := cs.handle(&chatterbox.Send{Text: handshake.Text, To: handshake.To}); err != nil {
+			return err
+		}
+	}
+
+	// Recv cannot be interrupted; it only returns once the client sends, or the stream ends after Chat
+	// returns. So it runs in a goroutine of its own, which Chat does not wait for, and which does nothing else.
+	reqs := make(chan *chatterbox.Send)
+	errs := make(chan error, 1)
+	errgroup.SafeGo(ctx, func(ctx context.Context) error {
+		for {
+			req, err := server.Recv()
+			if err != nil {
+				return err
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}, func(err error) {
+		errs <- err
+	})
+
 	for {
-		req, err := server.Recv()
-		if err != nil {
+		var req *chatterbox.Send
+		select {
+		case <-ctx.Done():
+			return nil // the send loop reports why
+		case err := <-errs:
 			return filterServerError(err)
+		case req = <-reqs:
 		}
+		if err := cs.handle(req); err != nil {
+			return err
+		}
+	}
+}
 
-		s.model.Chat(name, req.Text)
-		log.Printf("%s: %s", name, req.Text)
+// handle handles a single message from the member.
+func (cs *chatSession) handle(req *chatterbox.Send) error {
+	if !cs.limiter.allow(time.Now()) {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded: at most %v messages per second", cs.rateLimit)
+	}
+	if cs.maxTextLength > 0 && utf8.RuneCountInString(req.Text) > cs.maxTextLength {
+		return status.Errorf(codes.InvalidArgument, "message must be at most %d characters", cs.maxTextLength)
+	}
+	if req.Nick != "" {
+		if err := cs.rename(req.Nick); err != nil {
+			cs.reportError(err)
+			return nil // keep the rest of the message from going out under the old nickname
+		}
+	}
+	name := cs.Name()
+	if req.Presence != nil {
+		cs.room.SetPresence(name, *req.Presence)
+	}
+	switch {
+	case req.Text == "":
+		// Nothing to say.
+	case req.To != "":
+		if err := cs.nicks.Deliver(&chatterbox.Event{
+			Who:  name,
+			What: chatterbox.What_DM,
+			Text: req.Text,
+			Room: cs.room.Name(),
+			To:   req.To,
+		}); err != nil {
+			cs.reportError(err)
+			return nil
+		}
+		log.Printf("%s->%s: %s", name, req.To, req.Text)
+	default:
+		cs.room.Chat(name, req.Text)
+		log.Printf("%s@%s: %s", name, cs.room.Name(), req.Text)
 	}
+	return nil
 }
 
 // commonServerStream intersects ChatterBox_ChatServer and ChatterBox_MonitorServer
@@ -71,34 +438,22 @@ type commonServerStream interface {
 	Send(*chatterbox.Event) error
 }
 
-func (s *Server) sendLoop(server commonServerStream) error {
-	members, eventPromise := s.model.ReadAndSubscribe()
-
-	// Send the initial members.
-	for _, m := range members {
-		if err := server.Send(&chatterbox.Event{
-			Who:  m,
-			What: chatterbox.What_JOIN,
-		}); err != nil {
-			return filterServerError(err)
-		}
-	}
-
-	// Signal ready.
-	if err := server.Send(&chatterbox.Event{
-		What: chatterbox.What_INITIALIZED,
-	}); err != nil {
+// sendLoop sends the room's initial state, then its events along with any direct messages from inbox,
+// until ctx is done.
+func (s *Server) sendLoop(ctx context.Context, room *Room, resumeAfter int64, inbox <-chan *chatterbox.Event, server commonServerStream) error {
+	eventPromise, err := s.sendInitial(room, resumeAfter, server)
+	if err != nil {
 		return filterServerError(err)
 	}
 
 	for {
 		select {
-		case <-server.Context().Done():
-			return nil
+		case <-ctx.Done():
+			return filterServerError(context.Cause(ctx))
 		case <-eventPromise.Ready():
 			evt, nextPromise := eventPromise.Next()
 			if nextPromise == nil {
-				// end of stream, should never happen
+				// End of stream: the server is shutting down, and we have sent SERVER_SHUTDOWN.
 				return nil
 			}
 			eventPromise = nextPromise
@@ -106,6 +461,55 @@ func (s *Server) sendLoop(server commonServerStream) error {
 			if err := server.Send(evt.(*chatterbox.Event)); err != nil {
 				return filterServerError(err)
 			}
+		case dm := <-inbox:
+			if err := server.Send(dm); err != nil {
+				return filterServerError(err)
+			}
 		}
 	}
 }
+
+// sendInitial sends the events missed since resumeAfter if possible, or else the room's members and
+// recent history, followed by INITIALIZED. It returns a Promise for all subsequent events.
+func (s *Server) sendInitial(room *Room, resumeAfter int64, server commonServerStream) (eventstream.Promise, error) {
+	if resumeAfter != 0 {
+		if events, seq, eventPromise, ok := room.Resume(resumeAfter); ok {
+			for _, evt := range events {
+				if err := server.Send(evt); err != nil {
+					return nil, err
+				}
+			}
+			return eventPromise, server.Send(&chatterbox.Event{
+				What:    chatterbox.What_INITIALIZED,
+				Room:    room.Name(),
+				Seq:     seq,
+				Resumed: true,
+			})
+		}
+	}
+
+	joins, history, seq, eventPromise := room.ReadAndSubscribe()
+
+	// Send the initial members.
+	for _, evt := range joins {
+		if err := server.Send(evt); err != nil {
+			return nil, err
+		}
+	}
+
+	// Send recent history, marked as such.
+	for _, evt := range history {
+		evt = proto.Clone(evt).(*chatterbox.Event)
+		evt.History = true
+		if err := server.Send(evt); err != nil {
+			return nil, err
+		}
+	}
+
+	// Signal ready.
+	return eventPromise, server.Send(&chatterbox.Event{
+		What: chatterbox.What_INITIALIZED,
+		Room: room.Name(),
+		Seq:  seq,
+	})
+}
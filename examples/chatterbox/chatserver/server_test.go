@@ -0,0 +1,123 @@
+package chatserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fullstorydev/go/examples/chatterbox"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeChatStream is a Chat stream driven directly by the test. Receiving a nil message panics.
+type fakeChatStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	recv chan *chatterbox.Send
+	send func(*chatterbox.Event) error
+}
+
+func (f *fakeChatStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeChatStream) Recv() (*chatterbox.Send, error) {
+	select {
+	case req := <-f.recv:
+		if req == nil {
+			panic("recv failed")
+		}
+		return req, nil
+	case <-f.ctx.Done():
+		return nil, status.FromContextError(f.ctx.Err()).Err()
+	}
+}
+
+func (f *fakeChatStream) Send(evt *chatterbox.Event) error {
+	return f.send(evt)
+}
+
+func TestChatPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // as when Chat returns, ending any Recv
+	s := NewServer(Options{})
+	stream := &fakeChatStream{
+		ctx:  ctx,
+		recv: make(chan *chatterbox.Send, 1),
+		send: func(*chatterbox.Event) error {
+			panic("boom")
+		},
+	}
+	stream.recv <- &chatterbox.Send{Room: "test", Nick: "alice"}
+
+	// The panic ends the stream instead of crashing the server, and alice leaves.
+	if err := s.Chat(stream); status.Code(err) != codes.Internal {
+		t.Errorf("got: %v, want Internal", err)
+	}
+	if _, err := s.nicks.Lookup("alice"); status.Code(err) != codes.NotFound {
+		t.Errorf("got: %v, want alice's nickname released", err)
+	}
+}
+
+func TestChatRecvPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewServer(Options{})
+	stream := &fakeChatStream{
+		ctx:  ctx,
+		recv: make(chan *chatterbox.Send, 2),
+		send: func(*chatterbox.Event) error {
+			return nil
+		},
+	}
+	stream.recv <- &chatterbox.Send{Room: "test", Nick: "alice"}
+	stream.recv <- nil
+
+	if err := s.Chat(stream); status.Code(err) != codes.Internal {
+		t.Errorf("got: %v, want Internal", err)
+	}
+	if _, err := s.nicks.Lookup("alice"); status.Code(err) != codes.NotFound {
+		t.Errorf("got: %v, want alice's nickname released", err)
+	}
+}
+
+func TestChatRecvError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewServer(Options{MaxTextLength: 5})
+	sent := make(chan *chatterbox.Event, 100)
+	stream := &fakeChatStream{
+		ctx:  ctx,
+		recv: make(chan *chatterbox.Send, 2),
+		send: func(evt *chatterbox.Event) error {
+			sent <- evt
+			return nil
+		},
+	}
+	stream.recv <- &chatterbox.Send{Room: "test", Nick: "alice"}
+	stream.recv <- &chatterbox.Send{Text: "too long"}
+
+	// The recv loop's error ends the stream, although the send loop has nothing to send.
+	if err := s.Chat(stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got: %v, want InvalidArgument", err)
+	}
+
+	// Alice left exactly once.
+	room, err := s.rooms.Acquire("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.rooms.Release(room)
+	leaves := 0
+	room.mu.RLock()
+	room.forEachRetained(func(evt *chatterbox.Event) {
+		if evt.What == chatterbox.What_LEAVE && evt.Who == "alice" {
+			leaves++
+		}
+	})
+	room.mu.RUnlock()
+	if leaves != 1 {
+		t.Errorf("got %d LEAVE events, want 1", leaves)
+	}
+}
@@ -0,0 +1,306 @@
+package chatserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fullstorydev/go/examples/chatterbox"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultLogMaxAge is how long chat messages are kept in the log before they are compacted away.
+	DefaultLogMaxAge = 7 * 24 * time.Hour
+	// DefaultLogMaxSize is the size in bytes beyond which the oldest chat messages are compacted away.
+	DefaultLogMaxSize = 64 << 20
+
+	logFileName = "chat.log"
+)
+
+// StoreOptions configures a Store. The zero value uses the defaults.
+type StoreOptions struct {
+	// HistorySize is the number of recent chat messages per room to load into memory.
+	// Zero means DefaultHistorySize.
+	HistorySize int
+	// MaxAge is how long chat messages are kept. Zero means DefaultLogMaxAge; a negative value keeps them forever.
+	MaxAge time.Duration
+	// MaxSize is the size of the log in bytes to compact down to. Zero means DefaultLogMaxSize; a negative value
+	// means no limit.
+	MaxSize int64
+	// CompactInterval is how often the log is compacted by age. Zero means an hour.
+	CompactInterval time.Duration
+}
+
+// Store persists chat messages to an append-only log file, so that history survives restarts.
+//
+// Each line of the log is a JSON record holding the time a message was sent and the message itself.
+// The log is compacted when opened, periodically, and whenever it grows to twice its maximum size, by
+// rewriting it without the messages which are too old or which no longer fit.
+type Store struct {
+	path    string
+	opts    StoreOptions
+	stop    chan struct{}
+	stopped chan struct{}
+
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	history map[string][]*chatterbox.Event // the recent chat messages of each room, oldest first
+}
+
+type logRecord struct {
+	Time  time.Time       `json:"time"`
+	Event json.RawMessage `json:"event"`
+}
+
+// OpenStore opens the log in the given directory, creating both if necessary, and loads recent history.
+func OpenStore(dir string, opts StoreOptions) (*Store, error) {
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = DefaultHistorySize
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultLogMaxAge
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = DefaultLogMaxSize
+	}
+	if opts.CompactInterval <= 0 {
+		opts.CompactInterval = time.Hour
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		path:    filepath.Join(dir, logFileName),
+		opts:    opts,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	s.mu.Lock()
+	err := s.compactLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	go s.compactLoop()
+	return s, nil
+}
+
+// Close stops compaction and closes the log.
+func (s *Store) Close() error {
+	close(s.stop)
+	<-s.stopped
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// History returns copies of the recent chat messages of the given room, oldest first.
+func (s *Store) History(room string) []*chatterbox.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]*chatterbox.Event, 0, len(s.history[room]))
+	for _, evt := range s.history[room] {
+		ret = append(ret, proto.Clone(evt).(*chatterbox.Event))
+	}
+	return ret
+}
+
+// Append writes a chat message to the log.
+func (s *Store) Append(evt *chatterbox.Event) error {
+	evt = proto.Clone(evt).(*chatterbox.Event)
+	evt.Seq = 0 // sequence numbers do not survive restarts
+	line, err := marshalRecord(time.Now(), evt)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	s.remember(evt)
+
+	if s.opts.MaxSize > 0 && s.size > 2*s.opts.MaxSize {
+		return s.compactLocked()
+	}
+	return nil
+}
+
+// Compact rewrites the log without the chat messages which are too old or which no longer fit.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+func (s *Store) compactLoop() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.opts.CompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Compact(); err != nil {
+				log.Printf("compacting %s: %s", s.path, err)
+			}
+		}
+	}
+}
+
+// remember adds a chat message to the in-memory history of its room; the caller must hold the lock.
+func (s *Store) remember(evt *chatterbox.Event) {
+	h := append(s.history[evt.Room], evt)
+	if len(h) > s.opts.HistorySize {
+		h = h[len(h)-s.opts.HistorySize:]
+	}
+	s.history[evt.Room] = h
+}
+
+// compactLocked reads the log, rewrites it with only the records worth keeping, and reloads the in-memory
+// history from them; the caller must hold the lock.
+func (s *Store) compactLocked() error {
+	lines, err := s.readLog()
+	if err != nil {
+		return err
+	}
+
+	// Drop records which are too old, then the oldest of the rest until they fit.
+	var cutoff time.Time
+	if s.opts.MaxAge > 0 {
+		cutoff = time.Now().Add(-s.opts.MaxAge)
+	}
+	var keep []keptLine
+	var size int64
+	for _, l := range lines {
+		if l.time.Before(cutoff) {
+			continue
+		}
+		keep = append(keep, l)
+		size += int64(len(l.line))
+	}
+	for s.opts.MaxSize > 0 && size > s.opts.MaxSize && len(keep) > 0 {
+		size -= int64(len(keep[0].line))
+		keep = keep[1:]
+	}
+
+	// Write the survivors to a temporary file, and swap it in.
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, l := range keep {
+		_, _ = w.Write(l.line)
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if s.f != nil {
+		_ = s.f.Close()
+		s.f = nil
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.f, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	s.size = size
+
+	s.history = map[string][]*chatterbox.Event{}
+	for _, l := range keep {
+		s.remember(l.evt)
+	}
+	if dropped := len(lines) - len(keep); dropped > 0 {
+		log.Printf("compacted %s: dropped %d messages, kept %d", s.path, dropped, len(keep))
+	}
+	return nil
+}
+
+type keptLine struct {
+	time time.Time
+	evt  *chatterbox.Event
+	line []byte // including the trailing newline
+}
+
+// readLog reads every valid record from the log; a missing log is empty.
+func (s *Store) readLog() ([]keptLine, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ret []keptLine
+	r := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			t, evt, perr := unmarshalRecord(line)
+			if perr != nil {
+				// Most likely a write torn by a crash; skip it, and it goes away at the next compaction.
+				log.Printf("%s:%d: skipping bad record: %s", s.path, lineNum, perr)
+			} else {
+				if line[len(line)-1] != '\n' {
+					line = append(line, '\n')
+				}
+				ret = append(ret, keptLine{time: t, evt: evt, line: line})
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return ret, nil
+}
+
+func marshalRecord(t time.Time, evt *chatterbox.Event) ([]byte, error) {
+	b, err := protojson.Marshal(evt)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(logRecord{Time: t, Event: b})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func unmarshalRecord(line []byte) (time.Time, *chatterbox.Event, error) {
+	var rec logRecord
+	if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil {
+		return time.Time{}, nil, err
+	}
+	evt := &chatterbox.Event{}
+	if err := protojson.Unmarshal(rec.Event, evt); err != nil {
+		return time.Time{}, nil, err
+	}
+	if evt.What != chatterbox.What_CHAT || evt.Room == "" {
+		return time.Time{}, nil, fmt.Errorf("not a chat message: %v", evt)
+	}
+	return rec.Time, evt, nil
+}
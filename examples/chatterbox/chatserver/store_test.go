@@ -0,0 +1,151 @@
+package chatserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/go/examples/chatterbox"
+)
+
+func chat(room, text string) *chatterbox.Event {
+	return &chatterbox.Event{Who: "alice", What: chatterbox.What_CHAT, Text: text, Room: room}
+}
+
+func texts(events []*chatterbox.Event) []string {
+	var ret []string
+	for _, evt := range events {
+		ret = append(ret, evt.Text)
+	}
+	return ret
+}
+
+func assertTexts(t *testing.T, events []*chatterbox.Event, want ...string) {
+	t.Helper()
+	got := texts(events)
+	if len(got) != len(want) {
+		t.Fatalf("got: %q, want: %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got: %q, want: %q", got, want)
+		}
+	}
+}
+
+func openStore(t *testing.T, dir string, opts StoreOptions) *Store {
+	t.Helper()
+	s, err := OpenStore(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return s
+}
+
+func TestStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStore(dir, StoreOptions{HistorySize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, evt := range []*chatterbox.Event{chat("a", "1"), chat("b", "2"), chat("a", "3"), chat("a", "4")} {
+		if err := s.Append(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertTexts(t, s.History("a"), "3", "4")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a write torn by a crash.
+	f, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"time":"2020-`)
+	_ = f.Close()
+
+	s = openStore(t, dir, StoreOptions{HistorySize: 2})
+	assertTexts(t, s.History("a"), "3", "4")
+	assertTexts(t, s.History("b"), "2")
+	assertTexts(t, s.History("c"))
+}
+
+func TestStoreCompactByAge(t *testing.T) {
+	dir := t.TempDir()
+	old, err := marshalRecord(time.Now().Add(-2*time.Hour), chat("a", "old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, logFileName), old, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := openStore(t, dir, StoreOptions{MaxAge: time.Hour})
+	if err := s.Append(chat("a", "new")); err != nil {
+		t.Fatal(err)
+	}
+	assertTexts(t, s.History("a"), "new")
+
+	// Compaction also keeps the records which are new enough.
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	assertTexts(t, s.History("a"), "new")
+}
+
+func TestStoreCompactBySize(t *testing.T) {
+	dir := t.TempDir()
+	line, err := marshalRecord(time.Now(), chat("a", "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Room for three records, allowing for their times varying in length.
+	maxSize := int64(3*len(line) + len(line)/2)
+	s := openStore(t, dir, StoreOptions{MaxSize: maxSize})
+	logSize := func() int64 {
+		st, err := os.Stat(filepath.Join(dir, logFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Size()
+	}
+	for i := 0; i < 20; i++ {
+		if err := s.Append(chat("a", string(rune('a'+i)))); err != nil {
+			t.Fatal(err)
+		}
+		if size := logSize(); size > 2*maxSize {
+			t.Fatalf("log is %d bytes, want at most %d before compaction", size, 2*maxSize)
+		}
+	}
+
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	assertTexts(t, s.History("a"), "r", "s", "t")
+	if size := logSize(); size > maxSize {
+		t.Errorf("log is %d bytes, want at most %d", size, maxSize)
+	}
+}
+
+func TestStoreServesHistory(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, StoreOptions{})
+	m := NewMembersList("a", DefaultHistorySize, DefaultResumeWindow, s)
+	m.Chat("alice", "hello")
+	m.Chat("alice", "world")
+
+	// A new incarnation of the room, e.g. after a restart, starts with the persisted history.
+	m = NewMembersList("a", DefaultHistorySize, DefaultResumeWindow, s)
+	members, history, _, _ := m.ReadAndSubscribe()
+	if len(members) != 0 {
+		t.Errorf("got members: %q, want none", members)
+	}
+	assertTexts(t, history, "hello", "world")
+}
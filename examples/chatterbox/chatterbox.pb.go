@@ -9,7 +9,6 @@ package chatterbox
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
-	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -24,25 +23,46 @@ const (
 type What int32
 
 const (
-	What_INITIALIZED What = 0 // signals that the client is fully initialized
-	What_CHAT        What = 1
-	What_JOIN        What = 2
-	What_LEAVE       What = 3
+	What_INITIALIZED     What = 0 // signals that the client is fully initialized
+	What_CHAT            What = 1
+	What_JOIN            What = 2
+	What_LEAVE           What = 3
+	What_RENAME          What = 4
+	What_DM              What = 5 // a direct message, sent only to its recipient and not part of the room's sequence
+	What_PRESENCE        What = 6
+	What_KICK            What = 7  // who was kicked, and is about to LEAVE
+	What_BAN             What = 8  // who was kicked and banned, and is about to LEAVE
+	What_SERVER_SHUTDOWN What = 9  // the server is shutting down, with the reason in text; the stream ends after this
+	What_ERROR           What = 10 // a request failed, such as a rename to a taken nickname or a DM to nobody; sent only to the member who made it
 )
 
 // Enum value maps for What.
 var (
 	What_name = map[int32]string{
-		0: "INITIALIZED",
-		1: "CHAT",
-		2: "JOIN",
-		3: "LEAVE",
+		0:  "INITIALIZED",
+		1:  "CHAT",
+		2:  "JOIN",
+		3:  "LEAVE",
+		4:  "RENAME",
+		5:  "DM",
+		6:  "PRESENCE",
+		7:  "KICK",
+		8:  "BAN",
+		9:  "SERVER_SHUTDOWN",
+		10: "ERROR",
 	}
 	What_value = map[string]int32{
-		"INITIALIZED": 0,
-		"CHAT":        1,
-		"JOIN":        2,
-		"LEAVE":       3,
+		"INITIALIZED":     0,
+		"CHAT":            1,
+		"JOIN":            2,
+		"LEAVE":           3,
+		"RENAME":          4,
+		"DM":              5,
+		"PRESENCE":        6,
+		"KICK":            7,
+		"BAN":             8,
+		"SERVER_SHUTDOWN": 9,
+		"ERROR":           10,
 	}
 )
 
@@ -73,12 +93,66 @@ func (What) EnumDescriptor() ([]byte, []int) {
 	return file_chatterbox_proto_rawDescGZIP(), []int{0}
 }
 
+type Presence int32
+
+const (
+	Presence_ACTIVE Presence = 0
+	Presence_AWAY   Presence = 1
+	Presence_TYPING Presence = 2
+)
+
+// Enum value maps for Presence.
+var (
+	Presence_name = map[int32]string{
+		0: "ACTIVE",
+		1: "AWAY",
+		2: "TYPING",
+	}
+	Presence_value = map[string]int32{
+		"ACTIVE": 0,
+		"AWAY":   1,
+		"TYPING": 2,
+	}
+)
+
+func (x Presence) Enum() *Presence {
+	p := new(Presence)
+	*p = x
+	return p
+}
+
+func (x Presence) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Presence) Descriptor() protoreflect.EnumDescriptor {
+	return file_chatterbox_proto_enumTypes[1].Descriptor()
+}
+
+func (Presence) Type() protoreflect.EnumType {
+	return &file_chatterbox_proto_enumTypes[1]
+}
+
+func (x Presence) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Presence.Descriptor instead.
+func (Presence) EnumDescriptor() ([]byte, []int) {
+	return file_chatterbox_proto_rawDescGZIP(), []int{1}
+}
+
 type Send struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Text string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Text        string    `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Room        string    `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`                                         // only read from the first Send on a stream; empty means the default room
+	Nick        string    `protobuf:"bytes,3,opt,name=nick,proto3" json:"nick,omitempty"`                                         // requests a nickname; empty on the first Send means the server picks one
+	ResumeAfter int64     `protobuf:"varint,4,opt,name=resume_after,json=resumeAfter,proto3" json:"resume_after,omitempty"`       // only read from the first Send on a stream; see MonitorRequest
+	To          string    `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`                                             // if set, text is a direct message to the named member rather than to the room
+	Presence    *Presence `protobuf:"varint,6,opt,name=presence,proto3,enum=chatterbox.Presence,oneof" json:"presence,omitempty"` // if set, changes the member's presence; may be set on the first Send
 }
 
 func (x *Send) Reset() {
@@ -120,20 +194,307 @@ func (x *Send) GetText() string {
 	return ""
 }
 
+func (x *Send) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Send) GetNick() string {
+	if x != nil {
+		return x.Nick
+	}
+	return ""
+}
+
+func (x *Send) GetResumeAfter() int64 {
+	if x != nil {
+		return x.ResumeAfter
+	}
+	return 0
+}
+
+func (x *Send) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Send) GetPresence() Presence {
+	if x != nil && x.Presence != nil {
+		return *x.Presence
+	}
+	return Presence_ACTIVE
+}
+
+type MonitorRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Room string `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"` // empty means the default room
+	// If non-zero, the seq of the last event seen by a reconnecting client. If the server still retains
+	// the events since, it sends only those (rather than the members and history), followed by
+	// INITIALIZED with resumed set.
+	ResumeAfter int64 `protobuf:"varint,2,opt,name=resume_after,json=resumeAfter,proto3" json:"resume_after,omitempty"`
+}
+
+func (x *MonitorRequest) Reset() {
+	*x = MonitorRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_chatterbox_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MonitorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonitorRequest) ProtoMessage() {}
+
+func (x *MonitorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chatterbox_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonitorRequest.ProtoReflect.Descriptor instead.
+func (*MonitorRequest) Descriptor() ([]byte, []int) {
+	return file_chatterbox_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MonitorRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *MonitorRequest) GetResumeAfter() int64 {
+	if x != nil {
+		return x.ResumeAfter
+	}
+	return 0
+}
+
+type KickRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nick   string `protobuf:"bytes,1,opt,name=nick,proto3" json:"nick,omitempty"`
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Ban    bool   `protobuf:"varint,3,opt,name=ban,proto3" json:"ban,omitempty"` // if set, the nickname may not join again until unbanned, even if not currently connected
+}
+
+func (x *KickRequest) Reset() {
+	*x = KickRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_chatterbox_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KickRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickRequest) ProtoMessage() {}
+
+func (x *KickRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chatterbox_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickRequest.ProtoReflect.Descriptor instead.
+func (*KickRequest) Descriptor() ([]byte, []int) {
+	return file_chatterbox_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KickRequest) GetNick() string {
+	if x != nil {
+		return x.Nick
+	}
+	return ""
+}
+
+func (x *KickRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *KickRequest) GetBan() bool {
+	if x != nil {
+		return x.Ban
+	}
+	return false
+}
+
+type KickResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *KickResponse) Reset() {
+	*x = KickResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_chatterbox_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KickResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickResponse) ProtoMessage() {}
+
+func (x *KickResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chatterbox_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickResponse.ProtoReflect.Descriptor instead.
+func (*KickResponse) Descriptor() ([]byte, []int) {
+	return file_chatterbox_proto_rawDescGZIP(), []int{3}
+}
+
+type UnbanRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nick string `protobuf:"bytes,1,opt,name=nick,proto3" json:"nick,omitempty"`
+}
+
+func (x *UnbanRequest) Reset() {
+	*x = UnbanRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_chatterbox_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnbanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnbanRequest) ProtoMessage() {}
+
+func (x *UnbanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chatterbox_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnbanRequest.ProtoReflect.Descriptor instead.
+func (*UnbanRequest) Descriptor() ([]byte, []int) {
+	return file_chatterbox_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UnbanRequest) GetNick() string {
+	if x != nil {
+		return x.Nick
+	}
+	return ""
+}
+
+type UnbanResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UnbanResponse) Reset() {
+	*x = UnbanResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_chatterbox_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnbanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnbanResponse) ProtoMessage() {}
+
+func (x *UnbanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chatterbox_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnbanResponse.ProtoReflect.Descriptor instead.
+func (*UnbanResponse) Descriptor() ([]byte, []int) {
+	return file_chatterbox_proto_rawDescGZIP(), []int{5}
+}
+
 type Event struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Who  string `protobuf:"bytes,1,opt,name=who,proto3" json:"who,omitempty"`
-	What What   `protobuf:"varint,2,opt,name=what,proto3,enum=chatterbox.What" json:"what,omitempty"`
-	Text string `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	Who              string   `protobuf:"bytes,1,opt,name=who,proto3" json:"who,omitempty"`
+	What             What     `protobuf:"varint,2,opt,name=what,proto3,enum=chatterbox.What" json:"what,omitempty"`
+	Text             string   `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"` // for RENAME, the new nickname of who; for KICK, BAN and ERROR, the reason
+	Room             string   `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	History          bool     `protobuf:"varint,5,opt,name=history,proto3" json:"history,omitempty"`                                              // set on CHAT events replayed from before the client joined
+	Seq              int64    `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`                                                      // increases by one with each event in a room; on INITIALIZED, the seq of the last event it reflects
+	Resumed          bool     `protobuf:"varint,7,opt,name=resumed,proto3" json:"resumed,omitempty"`                                              // on INITIALIZED, set if the server sent only the events since resume_after
+	To               string   `protobuf:"bytes,8,opt,name=to,proto3" json:"to,omitempty"`                                                         // for DM, the recipient
+	Presence         Presence `protobuf:"varint,9,opt,name=presence,proto3,enum=chatterbox.Presence" json:"presence,omitempty"`                   // for PRESENCE, the new presence of who; on JOIN, their current presence
+	By               string   `protobuf:"bytes,10,opt,name=by,proto3" json:"by,omitempty"`                                                        // for KICK and BAN, the admin who kicked who
+	RetryAfterMillis int64    `protobuf:"varint,11,opt,name=retry_after_millis,json=retryAfterMillis,proto3" json:"retry_after_millis,omitempty"` // for SERVER_SHUTDOWN, how long clients should wait before reconnecting
 }
 
 func (x *Event) Reset() {
 	*x = Event{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_chatterbox_proto_msgTypes[1]
+		mi := &file_chatterbox_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -146,7 +507,7 @@ func (x *Event) String() string {
 func (*Event) ProtoMessage() {}
 
 func (x *Event) ProtoReflect() protoreflect.Message {
-	mi := &file_chatterbox_proto_msgTypes[1]
+	mi := &file_chatterbox_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -159,7 +520,7 @@ func (x *Event) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Event.ProtoReflect.Descriptor instead.
 func (*Event) Descriptor() ([]byte, []int) {
-	return file_chatterbox_proto_rawDescGZIP(), []int{1}
+	return file_chatterbox_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Event) GetWho() string {
@@ -183,35 +544,143 @@ func (x *Event) GetText() string {
 	return ""
 }
 
+func (x *Event) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *Event) GetHistory() bool {
+	if x != nil {
+		return x.History
+	}
+	return false
+}
+
+func (x *Event) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
+func (x *Event) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Event) GetPresence() Presence {
+	if x != nil {
+		return x.Presence
+	}
+	return Presence_ACTIVE
+}
+
+func (x *Event) GetBy() string {
+	if x != nil {
+		return x.By
+	}
+	return ""
+}
+
+func (x *Event) GetRetryAfterMillis() int64 {
+	if x != nil {
+		return x.RetryAfterMillis
+	}
+	return 0
+}
+
 var File_chatterbox_proto protoreflect.FileDescriptor
 
 var file_chatterbox_proto_rawDesc = []byte{
 	0x0a, 0x10, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x0a, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x1a, 0x1b,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
-	0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x1a, 0x0a, 0x04, 0x53,
-	0x65, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x22, 0x53, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74,
-	0x12, 0x10, 0x0a, 0x03, 0x77, 0x68, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x77,
-	0x68, 0x6f, 0x12, 0x24, 0x0a, 0x04, 0x77, 0x68, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
-	0x32, 0x10, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x57, 0x68,
-	0x61, 0x74, 0x52, 0x04, 0x77, 0x68, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x2a, 0x36, 0x0a, 0x04,
-	0x57, 0x68, 0x61, 0x74, 0x12, 0x0f, 0x0a, 0x0b, 0x49, 0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x49,
-	0x5a, 0x45, 0x44, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x43, 0x48, 0x41, 0x54, 0x10, 0x01, 0x12,
-	0x08, 0x0a, 0x04, 0x4a, 0x4f, 0x49, 0x4e, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x45, 0x41,
-	0x56, 0x45, 0x10, 0x03, 0x32, 0x79, 0x0a, 0x0a, 0x43, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x42,
-	0x6f, 0x78, 0x12, 0x31, 0x0a, 0x04, 0x43, 0x68, 0x61, 0x74, 0x12, 0x10, 0x2e, 0x63, 0x68, 0x61,
-	0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x1a, 0x11, 0x2e, 0x63,
-	0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22,
-	0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x38, 0x0a, 0x07, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72,
-	0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x11, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74,
-	0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42,
-	0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x66, 0x75,
-	0x6c, 0x6c, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x64, 0x65, 0x76, 0x2f, 0x67, 0x6f, 0x2f, 0x65, 0x78,
-	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x2f, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f,
-	0x78, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x74, 0x6f, 0x12, 0x0a, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x22, 0xb9,
+	0x01, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72,
+	0x6f, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x69, 0x63, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x69, 0x63, 0x6b, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x61, 0x66,
+	0x74, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6d,
+	0x65, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x35, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x65, 0x6e,
+	0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74,
+	0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x48, 0x00,
+	0x52, 0x08, 0x70, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a,
+	0x09, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x47, 0x0a, 0x0e, 0x4d, 0x6f,
+	0x6e, 0x69, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x72, 0x6f, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x6f, 0x6f, 0x6d,
+	0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x41, 0x66,
+	0x74, 0x65, 0x72, 0x22, 0x4b, 0x0a, 0x0b, 0x4b, 0x69, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x69, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x69, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x10,
+	0x0a, 0x03, 0x62, 0x61, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x62, 0x61, 0x6e,
+	0x22, 0x0e, 0x0a, 0x0c, 0x4b, 0x69, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x22, 0x0a, 0x0c, 0x55, 0x6e, 0x62, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x69, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x69, 0x63, 0x6b, 0x22, 0x0f, 0x0a, 0x0d, 0x55, 0x6e, 0x62, 0x61, 0x6e, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0xad, 0x02, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x77, 0x68, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x77, 0x68,
+	0x6f, 0x12, 0x24, 0x0a, 0x04, 0x77, 0x68, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x10, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x57, 0x68, 0x61,
+	0x74, 0x52, 0x04, 0x77, 0x68, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72,
+	0x6f, 0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x6f, 0x6f, 0x6d, 0x12,
+	0x18, 0x0a, 0x07, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65,
+	0x73, 0x75, 0x6d, 0x65, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x30, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63,
+	0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65,
+	0x72, 0x62, 0x6f, 0x78, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x08, 0x70,
+	0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x62, 0x79, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x62, 0x79, 0x12, 0x2c, 0x0a, 0x12, 0x72, 0x65, 0x74, 0x72, 0x79,
+	0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x6d, 0x69, 0x6c, 0x6c, 0x69, 0x73, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x10, 0x72, 0x65, 0x74, 0x72, 0x79, 0x41, 0x66, 0x74, 0x65, 0x72, 0x4d,
+	0x69, 0x6c, 0x6c, 0x69, 0x73, 0x2a, 0x8b, 0x01, 0x0a, 0x04, 0x57, 0x68, 0x61, 0x74, 0x12, 0x0f,
+	0x0a, 0x0b, 0x49, 0x4e, 0x49, 0x54, 0x49, 0x41, 0x4c, 0x49, 0x5a, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x08, 0x0a, 0x04, 0x43, 0x48, 0x41, 0x54, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x4a, 0x4f, 0x49,
+	0x4e, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x45, 0x41, 0x56, 0x45, 0x10, 0x03, 0x12, 0x0a,
+	0x0a, 0x06, 0x52, 0x45, 0x4e, 0x41, 0x4d, 0x45, 0x10, 0x04, 0x12, 0x06, 0x0a, 0x02, 0x44, 0x4d,
+	0x10, 0x05, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x52, 0x45, 0x53, 0x45, 0x4e, 0x43, 0x45, 0x10, 0x06,
+	0x12, 0x08, 0x0a, 0x04, 0x4b, 0x49, 0x43, 0x4b, 0x10, 0x07, 0x12, 0x07, 0x0a, 0x03, 0x42, 0x41,
+	0x4e, 0x10, 0x08, 0x12, 0x13, 0x0a, 0x0f, 0x53, 0x45, 0x52, 0x56, 0x45, 0x52, 0x5f, 0x53, 0x48,
+	0x55, 0x54, 0x44, 0x4f, 0x57, 0x4e, 0x10, 0x09, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f,
+	0x52, 0x10, 0x0a, 0x2a, 0x2c, 0x0a, 0x08, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x12,
+	0x0a, 0x0a, 0x06, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x41,
+	0x57, 0x41, 0x59, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x54, 0x59, 0x50, 0x49, 0x4e, 0x47, 0x10,
+	0x02, 0x32, 0xfa, 0x01, 0x0a, 0x0a, 0x43, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x42, 0x6f, 0x78,
+	0x12, 0x31, 0x0a, 0x04, 0x43, 0x68, 0x61, 0x74, 0x12, 0x10, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74,
+	0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x1a, 0x11, 0x2e, 0x63, 0x68, 0x61,
+	0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x28,
+	0x01, 0x30, 0x01, 0x12, 0x3c, 0x0a, 0x07, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x1a,
+	0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x4d, 0x6f, 0x6e, 0x69,
+	0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x63, 0x68, 0x61,
+	0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30,
+	0x01, 0x12, 0x3b, 0x0a, 0x04, 0x4b, 0x69, 0x63, 0x6b, 0x12, 0x17, 0x2e, 0x63, 0x68, 0x61, 0x74,
+	0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x4b, 0x69, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e,
+	0x4b, 0x69, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x3e,
+	0x0a, 0x05, 0x55, 0x6e, 0x62, 0x61, 0x6e, 0x12, 0x18, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65,
+	0x72, 0x62, 0x6f, 0x78, 0x2e, 0x55, 0x6e, 0x62, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x19, 0x2e, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78, 0x2e, 0x55,
+	0x6e, 0x62, 0x61, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x30,
+	0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x66, 0x75, 0x6c,
+	0x6c, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x64, 0x65, 0x76, 0x2f, 0x67, 0x6f, 0x2f, 0x65, 0x78, 0x61,
+	0x6d, 0x70, 0x6c, 0x65, 0x73, 0x2f, 0x63, 0x68, 0x61, 0x74, 0x74, 0x65, 0x72, 0x62, 0x6f, 0x78,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -226,25 +695,36 @@ func file_chatterbox_proto_rawDescGZIP() []byte {
 	return file_chatterbox_proto_rawDescData
 }
 
-var file_chatterbox_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_chatterbox_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_chatterbox_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_chatterbox_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_chatterbox_proto_goTypes = []interface{}{
-	(What)(0),             // 0: chatterbox.What
-	(*Send)(nil),          // 1: chatterbox.Send
-	(*Event)(nil),         // 2: chatterbox.Event
-	(*emptypb.Empty)(nil), // 3: google.protobuf.Empty
+	(What)(0),              // 0: chatterbox.What
+	(Presence)(0),          // 1: chatterbox.Presence
+	(*Send)(nil),           // 2: chatterbox.Send
+	(*MonitorRequest)(nil), // 3: chatterbox.MonitorRequest
+	(*KickRequest)(nil),    // 4: chatterbox.KickRequest
+	(*KickResponse)(nil),   // 5: chatterbox.KickResponse
+	(*UnbanRequest)(nil),   // 6: chatterbox.UnbanRequest
+	(*UnbanResponse)(nil),  // 7: chatterbox.UnbanResponse
+	(*Event)(nil),          // 8: chatterbox.Event
 }
 var file_chatterbox_proto_depIdxs = []int32{
-	0, // 0: chatterbox.Event.what:type_name -> chatterbox.What
-	1, // 1: chatterbox.ChatterBox.Chat:input_type -> chatterbox.Send
-	3, // 2: chatterbox.ChatterBox.Monitor:input_type -> google.protobuf.Empty
-	2, // 3: chatterbox.ChatterBox.Chat:output_type -> chatterbox.Event
-	2, // 4: chatterbox.ChatterBox.Monitor:output_type -> chatterbox.Event
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1, // 0: chatterbox.Send.presence:type_name -> chatterbox.Presence
+	0, // 1: chatterbox.Event.what:type_name -> chatterbox.What
+	1, // 2: chatterbox.Event.presence:type_name -> chatterbox.Presence
+	2, // 3: chatterbox.ChatterBox.Chat:input_type -> chatterbox.Send
+	3, // 4: chatterbox.ChatterBox.Monitor:input_type -> chatterbox.MonitorRequest
+	4, // 5: chatterbox.ChatterBox.Kick:input_type -> chatterbox.KickRequest
+	6, // 6: chatterbox.ChatterBox.Unban:input_type -> chatterbox.UnbanRequest
+	8, // 7: chatterbox.ChatterBox.Chat:output_type -> chatterbox.Event
+	8, // 8: chatterbox.ChatterBox.Monitor:output_type -> chatterbox.Event
+	5, // 9: chatterbox.ChatterBox.Kick:output_type -> chatterbox.KickResponse
+	7, // 10: chatterbox.ChatterBox.Unban:output_type -> chatterbox.UnbanResponse
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_chatterbox_proto_init() }
@@ -266,6 +746,66 @@ func file_chatterbox_proto_init() {
 			}
 		}
 		file_chatterbox_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MonitorRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_chatterbox_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KickRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_chatterbox_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KickResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_chatterbox_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnbanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_chatterbox_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnbanResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_chatterbox_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Event); i {
 			case 0:
 				return &v.state
@@ -278,13 +818,14 @@ func file_chatterbox_proto_init() {
 			}
 		}
 	}
+	file_chatterbox_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_chatterbox_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   2,
+			NumEnums:      2,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
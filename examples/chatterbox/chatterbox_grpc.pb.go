@@ -7,7 +7,6 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
-	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -19,10 +18,21 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ChatterBoxClient interface {
-	// Chat joins the chat room and sends chat messages.
+	// Chat joins a chat room and sends chat messages. The first Send selects the room and nickname, and may
+	// also carry a message.
+	//
+	// Like Monitor, the server first sends a JOIN for each current member, then recent CHAT history,
+	// then INITIALIZED, followed by live events. When resuming, it instead sends the missed events,
+	// then INITIALIZED. Direct messages to the member, and ERRORs for their requests which failed, are
+	// interleaved with the room's events.
 	Chat(ctx context.Context, opts ...grpc.CallOption) (ChatterBox_ChatClient, error)
-	// Monitor passively monitors the room.
-	Monitor(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (ChatterBox_MonitorClient, error)
+	// Monitor passively monitors a room.
+	Monitor(ctx context.Context, in *MonitorRequest, opts ...grpc.CallOption) (ChatterBox_MonitorClient, error)
+	// Kick disconnects a member, ending their stream with a PermissionDenied status, and optionally bans
+	// their nickname. Only admins may kick.
+	Kick(ctx context.Context, in *KickRequest, opts ...grpc.CallOption) (*KickResponse, error)
+	// Unban lifts a ban. Only admins may unban.
+	Unban(ctx context.Context, in *UnbanRequest, opts ...grpc.CallOption) (*UnbanResponse, error)
 }
 
 type chatterBoxClient struct {
@@ -64,7 +74,7 @@ func (x *chatterBoxChatClient) Recv() (*Event, error) {
 	return m, nil
 }
 
-func (c *chatterBoxClient) Monitor(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (ChatterBox_MonitorClient, error) {
+func (c *chatterBoxClient) Monitor(ctx context.Context, in *MonitorRequest, opts ...grpc.CallOption) (ChatterBox_MonitorClient, error) {
 	stream, err := c.cc.NewStream(ctx, &ChatterBox_ServiceDesc.Streams[1], "/chatterbox.ChatterBox/Monitor", opts...)
 	if err != nil {
 		return nil, err
@@ -96,14 +106,43 @@ func (x *chatterBoxMonitorClient) Recv() (*Event, error) {
 	return m, nil
 }
 
+func (c *chatterBoxClient) Kick(ctx context.Context, in *KickRequest, opts ...grpc.CallOption) (*KickResponse, error) {
+	out := new(KickResponse)
+	err := c.cc.Invoke(ctx, "/chatterbox.ChatterBox/Kick", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatterBoxClient) Unban(ctx context.Context, in *UnbanRequest, opts ...grpc.CallOption) (*UnbanResponse, error) {
+	out := new(UnbanResponse)
+	err := c.cc.Invoke(ctx, "/chatterbox.ChatterBox/Unban", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChatterBoxServer is the server API for ChatterBox service.
 // All implementations must embed UnimplementedChatterBoxServer
 // for forward compatibility
 type ChatterBoxServer interface {
-	// Chat joins the chat room and sends chat messages.
+	// Chat joins a chat room and sends chat messages. The first Send selects the room and nickname, and may
+	// also carry a message.
+	//
+	// Like Monitor, the server first sends a JOIN for each current member, then recent CHAT history,
+	// then INITIALIZED, followed by live events. When resuming, it instead sends the missed events,
+	// then INITIALIZED. Direct messages to the member, and ERRORs for their requests which failed, are
+	// interleaved with the room's events.
 	Chat(ChatterBox_ChatServer) error
-	// Monitor passively monitors the room.
-	Monitor(*emptypb.Empty, ChatterBox_MonitorServer) error
+	// Monitor passively monitors a room.
+	Monitor(*MonitorRequest, ChatterBox_MonitorServer) error
+	// Kick disconnects a member, ending their stream with a PermissionDenied status, and optionally bans
+	// their nickname. Only admins may kick.
+	Kick(context.Context, *KickRequest) (*KickResponse, error)
+	// Unban lifts a ban. Only admins may unban.
+	Unban(context.Context, *UnbanRequest) (*UnbanResponse, error)
 	mustEmbedUnimplementedChatterBoxServer()
 }
 
@@ -114,9 +153,15 @@ type UnimplementedChatterBoxServer struct {
 func (UnimplementedChatterBoxServer) Chat(ChatterBox_ChatServer) error {
 	return status.Errorf(codes.Unimplemented, "method Chat not implemented")
 }
-func (UnimplementedChatterBoxServer) Monitor(*emptypb.Empty, ChatterBox_MonitorServer) error {
+func (UnimplementedChatterBoxServer) Monitor(*MonitorRequest, ChatterBox_MonitorServer) error {
 	return status.Errorf(codes.Unimplemented, "method Monitor not implemented")
 }
+func (UnimplementedChatterBoxServer) Kick(context.Context, *KickRequest) (*KickResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Kick not implemented")
+}
+func (UnimplementedChatterBoxServer) Unban(context.Context, *UnbanRequest) (*UnbanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unban not implemented")
+}
 func (UnimplementedChatterBoxServer) mustEmbedUnimplementedChatterBoxServer() {}
 
 // UnsafeChatterBoxServer may be embedded to opt out of forward compatibility for this service.
@@ -157,7 +202,7 @@ func (x *chatterBoxChatServer) Recv() (*Send, error) {
 }
 
 func _ChatterBox_Monitor_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(emptypb.Empty)
+	m := new(MonitorRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -177,13 +222,58 @@ func (x *chatterBoxMonitorServer) Send(m *Event) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _ChatterBox_Kick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatterBoxServer).Kick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chatterbox.ChatterBox/Kick",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatterBoxServer).Kick(ctx, req.(*KickRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatterBox_Unban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatterBoxServer).Unban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chatterbox.ChatterBox/Unban",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatterBoxServer).Unban(ctx, req.(*UnbanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChatterBox_ServiceDesc is the grpc.ServiceDesc for ChatterBox service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var ChatterBox_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "chatterbox.ChatterBox",
 	HandlerType: (*ChatterBoxServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Kick",
+			Handler:    _ChatterBox_Kick_Handler,
+		},
+		{
+			MethodName: "Unban",
+			Handler:    _ChatterBox_Unban_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Chat",
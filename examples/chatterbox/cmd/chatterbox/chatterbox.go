@@ -8,6 +8,11 @@ import (
 	"log"
 	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fullstorydev/go/examples/chatterbox"
 	"github.com/fullstorydev/go/examples/chatterbox/chatclient"
@@ -15,26 +20,33 @@ import (
 	"google.golang.org/grpc"
 )
 
-const (
-	addr = "127.0.0.1:9000"
-)
-
 func main() {
 	flag.Parse()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Interrupting cancels ctx, so that the server can shut down gracefully; interrupting again kills.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	var err error
 	switch flag.Arg(0) {
 	case "":
-		err = fmt.Errorf(`choose one of: "server", "client", "monitor" `)
+		err = fmt.Errorf(`choose one of: "server", "client", "monitor", "kick", "unban", "gencert" `)
 	case "server":
-		err = runServer(ctx)
+		err = runServer(ctx, flag.Args()[1:])
 	case "client":
-		err = runClient(ctx)
+		err = runClient(ctx, flag.Args()[1:])
 	case "monitor":
-		err = runMonitor(ctx)
+		err = runMonitor(ctx, flag.Args()[1:])
+	case "gencert":
+		err = runGenCert(ctx, flag.Args()[1:])
+	case "kick":
+		err = runKick(ctx, flag.Args()[1:])
+	case "unban":
+		err = runUnban(ctx, flag.Args()[1:])
 	default:
 		err = fmt.Errorf("unknown command: %s", flag.Arg(0))
 	}
@@ -45,39 +57,147 @@ func main() {
 	}
 }
 
-func runServer(_ context.Context) error {
-	svr := grpc.NewServer()
-	chatterbox.RegisterChatterBoxServer(svr, chatserver.NewServer())
+func runServer(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	history := fs.Int("history", chatserver.DefaultHistorySize, "the number of recent chat messages to replay to new clients")
+	resumeWindow := fs.Int("resume-window", chatserver.DefaultResumeWindow, "the number of recent events to retain for reconnecting clients to resume from")
+	dataDir := fs.String("data-dir", "", "a directory in which to persist chat history across restarts; by default, history is kept in memory only")
+	logMaxAge := fs.Duration("log-max-age", chatserver.DefaultLogMaxAge, "how long to keep persisted chat messages; 0 keeps them forever")
+	logMaxSize := fs.Int64("log-max-size", chatserver.DefaultLogMaxSize, "the size in bytes to compact the persisted chat log down to; 0 means no limit")
+	rateLimit := fs.Float64("rate-limit", chatserver.DefaultRateLimit, "the number of messages per second each member may send, on average; 0 means no limit")
+	rateBurst := fs.Int("rate-burst", chatserver.DefaultRateBurst, "the number of messages each member may send at once")
+	maxTextLength := fs.Int("max-text-length", chatserver.DefaultMaxTextLength, "the maximum length of a message, in characters; 0 means no limit")
+	admins := fs.String("admins", "", "comma-separated nicknames of the authenticated principals who may kick and ban")
+	banFile := fs.String("ban-file", "", "a file in which to persist banned nicknames; by default, bans.txt in -data-dir if set, or else bans are kept in memory only")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "how long to wait for streams to end when shutting down, before closing them")
+	retryAfter := fs.Duration("retry-after", 5*time.Second, "how long clients should wait before reconnecting, when the server shuts down")
+	sf := addServerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *history == 0 {
+		*history = -1 // disabled
+	}
+	if *resumeWindow == 0 {
+		*resumeWindow = -1 // disabled
+	}
+	if *rateLimit == 0 {
+		*rateLimit = -1 // no limit
+	}
+	if *maxTextLength == 0 {
+		*maxTextLength = -1 // no limit
+	}
+	if *banFile == "" && *dataDir != "" {
+		*banFile = filepath.Join(*dataDir, "bans.txt")
+	}
 
-	lis, err := net.Listen("tcp", addr)
+	opts, err := sf.serverOptions()
+	if err != nil {
+		return err
+	}
+
+	var store *chatserver.Store
+	if *dataDir != "" {
+		if *logMaxAge == 0 {
+			*logMaxAge = -1 // forever
+		}
+		if *logMaxSize == 0 {
+			*logMaxSize = -1 // no limit
+		}
+		store, err = chatserver.OpenStore(*dataDir, chatserver.StoreOptions{
+			HistorySize: *history,
+			MaxAge:      *logMaxAge,
+			MaxSize:     *logMaxSize,
+		})
+		if err != nil {
+			return fmt.Errorf("open store: %w", err)
+		}
+		defer store.Close()
+	}
+
+	bans, err := chatserver.OpenBanList(*banFile)
+	if err != nil {
+		return fmt.Errorf("open ban list: %w", err)
+	}
+
+	svr := grpc.NewServer(opts...)
+	chat := chatserver.NewServer(chatserver.Options{
+		HistorySize:   *history,
+		ResumeWindow:  *resumeWindow,
+		Store:         store,
+		RateLimit:     *rateLimit,
+		RateBurst:     *rateBurst,
+		MaxTextLength: *maxTextLength,
+		Bans:          bans,
+		Admins:        splitList(*admins),
+	})
+	chatterbox.RegisterChatterBoxServer(svr, chat)
+
+	lis, err := net.Listen("tcp", *sf.listen)
 	if err != nil {
 		return fmt.Errorf("listen: %w", err)
 	}
-	log.Println("Listening on ", addr)
-	return svr.Serve(lis)
+	log.Println("Listening on ", lis.Addr())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- svr.Serve(lis)
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Tell clients we are going away, which ends their streams, then give any stragglers until the deadline.
+	chat.Shutdown("stopped by operator", *retryAfter)
+	stopped := make(chan struct{})
+	go func() {
+		svr.GracefulStop()
+		close(stopped)
+	}()
+	timer := time.NewTimer(*shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		log.Printf("streams still open after %s; closing them", *shutdownTimeout)
+		svr.Stop()
+	}
+	return <-serveErr
 }
 
-func runClient(ctx context.Context) error {
-	log.Println("Dialing ", addr)
-	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure())
+func runClient(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("client", flag.ExitOnError)
+	room := fs.String("room", chatterbox.DefaultRoom, "the room to join")
+	nick := fs.String("nick", "", "the nickname to use; by default, the server picks one, or uses the nickname you authenticate as")
+	df := addDialFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := df.dial(ctx)
 	if err != nil {
-		return fmt.Errorf("dial: %w", err)
+		return err
 	}
 	defer conn.Close()
 
 	// Read lines off the terminal, try to send through channel.
 	ctx, cancel := context.WithCancel(ctx)
-	chatInput := make(chan string)
+	chatInput := make(chan *chatterbox.Send)
 	go func() {
 		// when exiting for any reason, cancel the stream context.
 		defer cancel()
 		defer close(chatInput)
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
+			msg := parseInput(scanner.Text())
+			if msg == nil {
+				continue
+			}
 			select {
 			case <-ctx.Done():
 				return
-			case chatInput <- scanner.Text():
+			case chatInput <- msg:
 			}
 		}
 
@@ -86,16 +206,111 @@ func runClient(ctx context.Context) error {
 		}
 	}()
 
-	return chatclient.RunClient(ctx, chatInput, chatterbox.NewChatterBoxClient(conn))
+	return chatclient.RunClient(ctx, *room, *nick, chatInput, chatterbox.NewChatterBoxClient(conn))
+}
+
+// parseInput converts a line of terminal input into a message, or returns nil after printing usage if it
+// is not a valid command. Commands are:
+//
+//	/nick <name>         changes the nickname
+//	/msg <name> <text>   sends a direct message; quote names with spaces, as in /msg "User 1" hi
+//	/away                marks us as away
+//	/typing              marks us as typing, until we send a message
+//	/back                marks us as active again
+//
+// All other lines are chat messages.
+func parseInput(line string) *chatterbox.Send {
+	cmd, arg := line, ""
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	switch cmd {
+	case "/nick":
+		if arg != "" {
+			return &chatterbox.Send{Nick: arg}
+		}
+	case "/msg":
+		if to, text := splitName(arg); to != "" && text != "" {
+			return &chatterbox.Send{To: to, Text: text}
+		}
+	case "/away":
+		return &chatterbox.Send{Presence: chatterbox.Presence_AWAY.Enum()}
+	case "/typing":
+		return &chatterbox.Send{Presence: chatterbox.Presence_TYPING.Enum()}
+	case "/back":
+		return &chatterbox.Send{Presence: chatterbox.Presence_ACTIVE.Enum()}
+	default:
+		return &chatterbox.Send{Text: line}
+	}
+	log.Printf("usage: /nick <name> | /msg <name> <text> | /away | /typing | /back")
+	return nil
+}
+
+// splitName splits a leading nickname, which may be quoted, from the rest of s.
+func splitName(s string) (name, rest string) {
+	if strings.HasPrefix(s, `"`) {
+		if i := strings.IndexByte(s[1:], '"'); i >= 0 {
+			return s[1 : i+1], strings.TrimSpace(s[i+2:])
+		}
+		return "", ""
+	}
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+	return s, ""
 }
 
-func runMonitor(ctx context.Context) error {
-	log.Println("Dialing ", addr)
-	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure())
+func runMonitor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	room := fs.String("room", chatterbox.DefaultRoom, "the room to monitor")
+	df := addDialFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := df.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return chatclient.RunMonitor(ctx, *room, chatterbox.NewChatterBoxClient(conn))
+}
+
+func runKick(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("kick", flag.ExitOnError)
+	nick := fs.String("nick", "", "the nickname to kick")
+	reason := fs.String("reason", "", "the reason, shown to the room")
+	ban := fs.Bool("ban", false, "also ban the nickname, even if it is not connected")
+	df := addDialFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := df.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = chatterbox.NewChatterBoxClient(conn).Kick(ctx, &chatterbox.KickRequest{Nick: *nick, Reason: *reason, Ban: *ban})
+	return err
+}
+
+func runUnban(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("unban", flag.ExitOnError)
+	nick := fs.String("nick", "", "the nickname to unban")
+	df := addDialFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := df.dial(ctx)
 	if err != nil {
-		return fmt.Errorf("dial: %w", err)
+		return err
 	}
 	defer conn.Close()
 
-	return chatclient.RunMonitor(ctx, chatterbox.NewChatterBoxClient(conn))
+	_, err = chatterbox.NewChatterBoxClient(conn).Unban(ctx, &chatterbox.UnbanRequest{Nick: *nick})
+	return err
 }
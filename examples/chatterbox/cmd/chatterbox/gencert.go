@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runGenCert generates a self-signed CA, and a server certificate and client certificates signed by it,
+// so that TLS and mutual TLS can be tried out without any other tools.
+func runGenCert(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("gencert", flag.ExitOnError)
+	dir := fs.String("dir", "certs", "the directory to write certificates and keys to")
+	hosts := fs.String("hosts", "127.0.0.1,localhost", "comma-separated host names and IP addresses for the server certificate")
+	clients := fs.String("clients", "", "comma-separated nicknames to generate client certificates for, written to client-<nickname>.pem")
+	validFor := fs.Duration("valid-for", 365*24*time.Hour, "how long the certificates are valid for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	nicks := splitList(*clients)
+	for _, nick := range nicks {
+		if strings.ContainsAny(nick, `/\`) {
+			return fmt.Errorf("nickname %q must not contain path separators", nick)
+		}
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+
+	g := &certGen{dir: *dir, notAfter: time.Now().Add(*validFor)}
+	caTmpl := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "chatterbox CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	ca, caKey, err := g.generate("ca", caTmpl, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	serverTmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "chatterbox server"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range splitList(*hosts) {
+		if ip := net.ParseIP(h); ip != nil {
+			serverTmpl.IPAddresses = append(serverTmpl.IPAddresses, ip)
+		} else {
+			serverTmpl.DNSNames = append(serverTmpl.DNSNames, h)
+		}
+	}
+	if _, _, err := g.generate("server", serverTmpl, ca, caKey); err != nil {
+		return err
+	}
+
+	for _, nick := range nicks {
+		clientTmpl := &x509.Certificate{
+			Subject:     pkix.Name{CommonName: nick},
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		// The prefix keeps nicknames such as "ca" or "server" from overwriting the other key pairs.
+		if _, _, err := g.generate("client-"+nick, clientTmpl, ca, caKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type certGen struct {
+	dir      string
+	notAfter time.Time
+}
+
+// generate creates a key and a certificate from tmpl, signed by parent, or self-signed if parent is nil,
+// and writes them to <name>.pem and <name>-key.pem.
+func (g *certGen) generate(name string, tmpl, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour) // tolerate clock skew
+	tmpl.NotAfter = g.notAfter
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPath := filepath.Join(g.dir, name+".pem")
+	keyPath := filepath.Join(g.dir, name+"-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		return nil, nil, err
+	}
+	log.Printf("wrote %s and %s", certPath, keyPath)
+	return cert, key, nil
+}
+
+func splitList(s string) []string {
+	var ret []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fullstorydev/go/examples/chatterbox/chatclient"
+	"github.com/fullstorydev/go/examples/chatterbox/chatserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const defaultAddr = "127.0.0.1:9000"
+
+// serverFlags configures how the server listens, and whom it lets in.
+type serverFlags struct {
+	listen   *string
+	cert     *string
+	key      *string
+	clientCA *string
+	tokens   *string
+}
+
+func addServerFlags(fs *flag.FlagSet) *serverFlags {
+	return &serverFlags{
+		listen:   fs.String("listen", defaultAddr, "the address to listen on"),
+		cert:     fs.String("tls-cert", "", "a PEM certificate file to serve TLS with; requires -tls-key"),
+		key:      fs.String("tls-key", "", "the PEM private key file for -tls-cert"),
+		clientCA: fs.String("tls-client-ca", "", "a PEM CA certificate file to verify client certificates against, for mutual TLS; clients chat as the common name of their certificate"),
+		tokens:   fs.String("tokens", "", `a file of bearer tokens, one "<token> <nickname>" per line; clients chat as the nickname of their token`),
+	}
+}
+
+// serverOptions returns the options to create the server with.
+func (f *serverFlags) serverOptions() ([]grpc.ServerOption, error) {
+	if *f.cert == "" && *f.key == "" {
+		if *f.clientCA != "" || *f.tokens != "" {
+			return nil, errors.New("-tls-client-ca and -tokens require -tls-cert and -tls-key")
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(*f.cert, *f.key)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	auth := &chatserver.Auth{}
+	if *f.clientCA != "" {
+		if cfg.ClientCAs, err = loadCertPool(*f.clientCA); err != nil {
+			return nil, err
+		}
+		auth.ClientCerts = true
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if *f.tokens != "" {
+		if auth.Tokens, err = chatserver.LoadTokens(*f.tokens); err != nil {
+			return nil, fmt.Errorf("load tokens: %w", err)
+		}
+		if auth.ClientCerts {
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven // either will do
+		}
+	}
+
+	opts := []grpc.ServerOption{grpc.Creds(credentials.NewTLS(cfg))}
+	if auth.ClientCerts || auth.Tokens != nil {
+		opts = append(opts, grpc.StreamInterceptor(auth.StreamInterceptor), grpc.UnaryInterceptor(auth.UnaryInterceptor))
+	}
+	return opts, nil
+}
+
+// dialFlags configures how clients, monitors and admin commands connect to the server, and who they are.
+type dialFlags struct {
+	addr       *string
+	useTLS     *bool
+	ca         *string
+	cert       *string
+	key        *string
+	serverName *string
+	token      *string
+}
+
+func addDialFlags(fs *flag.FlagSet) *dialFlags {
+	return &dialFlags{
+		addr:       fs.String("addr", defaultAddr, "the address of the server"),
+		useTLS:     fs.Bool("tls", false, "connect with TLS; implied by the other -tls flags"),
+		ca:         fs.String("tls-ca", "", "a PEM CA certificate file to verify the server against; by default, the system roots"),
+		cert:       fs.String("tls-cert", "", "a PEM client certificate file, for mutual TLS; requires -tls-key"),
+		key:        fs.String("tls-key", "", "the PEM private key file for -tls-cert"),
+		serverName: fs.String("tls-server-name", "", "the name to verify the server's certificate against; by default, the host of -addr"),
+		token:      fs.String("token", "", "a bearer token to authenticate with; requires TLS"),
+	}
+}
+
+// dial connects to the server.
+func (f *dialFlags) dial(ctx context.Context) (*grpc.ClientConn, error) {
+	useTLS := *f.useTLS || *f.ca != "" || *f.cert != "" || *f.key != "" || *f.serverName != ""
+	var opts []grpc.DialOption
+	if useTLS {
+		cfg := &tls.Config{
+			ServerName: *f.serverName,
+			MinVersion: tls.VersionTLS12,
+		}
+		if *f.ca != "" {
+			var err error
+			if cfg.RootCAs, err = loadCertPool(*f.ca); err != nil {
+				return nil, err
+			}
+		}
+		if *f.cert != "" || *f.key != "" {
+			cert, err := tls.LoadX509KeyPair(*f.cert, *f.key)
+			if err != nil {
+				return nil, fmt.Errorf("load TLS key pair: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if *f.token != "" {
+		if !useTLS {
+			return nil, errors.New("-token requires TLS")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(chatclient.TokenCredentials(*f.token)))
+	}
+
+	log.Println("Dialing ", *f.addr)
+	conn, err := grpc.DialContext(ctx, *f.addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	return conn, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
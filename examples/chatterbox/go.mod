@@ -3,6 +3,7 @@ module github.com/fullstorydev/go/examples/chatterbox
 go 1.13
 
 require (
+	github.com/fullstorydev/go/errgroup v0.0.0-20261018231735-df27ce0bc8df
 	github.com/fullstorydev/go/eventstream v0.0.0-20211031163310-f3206704c9cb
 	golang.org/x/net v0.33.0 // indirect
 	google.golang.org/grpc v1.56.3
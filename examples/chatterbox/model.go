@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// DefaultRoom is the room joined by clients which do not name one.
+const DefaultRoom = "lobby"
+
 // MembersModel is a basic model object representing the current users in the room.
 // Used by both client and server.  For this app, we've made a couple of design choices:
 //
@@ -24,6 +27,14 @@ func (mm MembersModel) Remove(name string) {
 	delete(mm, name)
 }
 
+// Rename replaces a user in the set with their new name.
+func (mm MembersModel) Rename(oldName, newName string) {
+	if _, ok := mm[oldName]; ok {
+		delete(mm, oldName)
+		mm[newName] = struct{}{}
+	}
+}
+
 // Strings returns a copy of the current list of members.
 func (mm MembersModel) Strings() []string {
 	var ret []string
@@ -64,6 +75,17 @@ func (mma MembersModelAlt) ApplyMutation(evt *Event) MembersModelAlt {
 			}
 		}
 		return ret
+	case What_RENAME:
+		// Create a new version with the member renamed.
+		ret := make(MembersModelAlt, 0, len(mma))
+		for _, v := range mma {
+			if v == evt.Who {
+				v = evt.Text
+			}
+			ret = append(ret, v)
+		}
+		sort.Strings(ret)
+		return ret
 	default:
 		return mma // other events are no-ops
 	}
@@ -0,0 +1,36 @@
+package chatterbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMembersModelRename(t *testing.T) {
+	mm := MembersModel{}
+	mm.Add("bob")
+	mm.Add("carol")
+	mm.Rename("bob", "alice")
+	mm.Rename("dave", "erin") // not a member
+	if got, want := mm.Strings(), []string{"alice", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestMembersModelAltRename(t *testing.T) {
+	var mma MembersModelAlt
+	for _, name := range []string{"bob", "carol"} {
+		mma = mma.ApplyMutation(&Event{What: What_JOIN, Who: name})
+	}
+	renamed := mma.ApplyMutation(&Event{What: What_RENAME, Who: "bob", Text: "alice"})
+	if got, want := renamed.Strings(), []string{"alice", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	// The original is unchanged.
+	if got, want := mma.Strings(), []string{"bob", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	// Renaming someone who is not a member changes nothing.
+	if got, want := mma.ApplyMutation(&Event{What: What_RENAME, Who: "dave", Text: "erin"}).Strings(), mma.Strings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}